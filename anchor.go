@@ -0,0 +1,106 @@
+package main
+
+import (
+	"unicode/utf16"
+
+	"github.com/freitagsrunde/modulist/db"
+)
+
+// Constants
+
+const (
+	// Longest passage in UTF-16 code units feedback
+	// can be anchored to.
+	MAX_ANCHOR_LENGTH = 2000
+)
+
+// Functions
+
+// AnchorSlice returns the passage of supplied text between
+// Start and End, counted in UTF-16 code units as browsers
+// do. Offsets outside of the text are reported.
+func AnchorSlice(Text string, Start int, End int) (string, bool) {
+
+	Units := utf16.Encode([]rune(Text))
+
+	if (Start < 0) || (End <= Start) || (End > len(Units)) {
+		return "", false
+	}
+
+	return string(utf16.Decode(Units[Start:End])), true
+}
+
+// ResolveAnchor locates a quoted passage in supplied text.
+// If the passage is still found at its original offsets,
+// these are returned. Otherwise, e.g. after a re-import of
+// the module changed the text before the passage, the
+// occurrence of the quote closest to the original start is
+// chosen. If the quote is not part of the text anymore,
+// the anchor is reported as lost.
+func ResolveAnchor(Text string, Start int, End int, Quote string) (int, int, bool) {
+
+	if Passage, ok := AnchorSlice(Text, Start, End); ok && (Passage == Quote) {
+		return Start, End, true
+	}
+
+	Units := utf16.Encode([]rune(Text))
+	QuoteUnits := utf16.Encode([]rune(Quote))
+
+	if len(QuoteUnits) == 0 {
+		return 0, 0, false
+	}
+
+	Best := -1
+	for i := 0; (i + len(QuoteUnits)) <= len(Units); i++ {
+
+		Matches := true
+		for j := range QuoteUnits {
+
+			if Units[i+j] != QuoteUnits[j] {
+				Matches = false
+
+				break
+			}
+		}
+
+		if Matches && ((Best < 0) || (distance(i, Start) < distance(Best, Start))) {
+			Best = i
+		}
+	}
+
+	if Best < 0 {
+		return 0, 0, false
+	}
+
+	return Best, (Best + len(QuoteUnits)), true
+}
+
+// distance returns the absolute difference of two offsets.
+func distance(a int, b int) int {
+
+	if a > b {
+		return a - b
+	}
+
+	return b - a
+}
+
+// ResolveAnchors updates the offsets of all anchored
+// discussions to the current text of supplied module
+// and marks whether their passage was found.
+func ResolveAnchors(Module db.Module, Threads []db.Feedback) {
+
+	for i := range Threads {
+
+		if Threads[i].AnchorField == "" {
+			continue
+		}
+
+		Text, ok := Module.AnchorText(Threads[i].AnchorField)
+		if !ok {
+			continue
+		}
+
+		Threads[i].AnchorStart, Threads[i].AnchorEnd, Threads[i].AnchorFound = ResolveAnchor(Text, Threads[i].AnchorStart, Threads[i].AnchorEnd, Threads[i].AnchorQuote)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"net/http"
+
+	"github.com/freitagsrunde/modulist/db"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/satori/go.uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Functions
+
+// newTestApp returns an app backed by an in-memory SQLite
+// database holding the default roles. All routes are
+// registered, middleware like CSRF protection is not.
+func newTestApp(t *testing.T) *App {
+
+	t.Setenv("APP_JWT_SIGNING_SECRET", "test-signing-secret")
+
+	DB, err := gorm.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("opening test database failed: %s", err)
+	}
+	t.Cleanup(func() { DB.Close() })
+
+	// Every connection would get its own in-memory database.
+	DB.DB().SetMaxOpenConns(1)
+	db.MigrateTables(DB)
+
+	gin.SetMode(gin.TestMode)
+
+	app := &App{
+		HashCost:       bcrypt.MinCost,
+		JWTValidFor:    time.Hour,
+		CookieSameSite: http.SameSiteStrictMode,
+		Router:         gin.New(),
+		DB:             DB,
+	}
+	app.DefineRoutes()
+
+	return app
+}
+
+// createTestUser saves an account with supplied mail
+// address and password holding the named roles.
+func createTestUser(t *testing.T, app *App, Mail string, Password string, Enabled bool, RoleNames ...string) db.User {
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(Password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hashing password failed: %s", err)
+	}
+
+	User := db.User{
+		ID:           fmt.Sprintf("%s", uuid.NewV4()),
+		FirstName:    "Test",
+		LastName:     Mail,
+		Mail:         Mail,
+		MailVerified: true,
+		PasswordHash: string(hash),
+		StatusGroup:  db.STATUS_GROUP_OTHER,
+		Privileges:   db.PRIVILEGE_MIGRATED,
+		Roles:        db.FindRoles(app.DB, RoleNames),
+		Enabled:      Enabled,
+	}
+
+	if err := app.DB.Create(&User).Error; err != nil {
+		t.Fatalf("saving user '%s' failed: %s", Mail, err)
+	}
+
+	return User
+}
+
+// loadTestUser reads the account with supplied
+// mail address including its roles.
+func loadTestUser(app *App, Mail string) db.User {
+
+	var User db.User
+	app.DB.Preload("Roles").First(&User, "lower(\"mail\") = lower(?)", Mail)
+
+	return User
+}
+
+// sessionCookie returns the session token set by
+// a response, nil if no session was created.
+func sessionCookie(Response *http.Response) *http.Cookie {
+
+	for _, Cookie := range Response.Cookies() {
+
+		if (Cookie.Name == "Token") && (Cookie.Value != "") {
+			return Cookie
+		}
+	}
+
+	return nil
+}
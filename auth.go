@@ -2,15 +2,19 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"os"
 	"time"
 
+	"crypto/rand"
 	"net/http"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/freitagsrunde/modulist/db"
 	"github.com/gin-gonic/gin"
+	"github.com/satori/go.uuid"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Functions
@@ -43,16 +47,17 @@ func (app *App) CreateSession(c *gin.Context, User db.User) {
 		log.Fatalf("[CreateJWT] Creating JWT went wrong: %s.\nTerminating.", err)
 	}
 
-	// TODO: Set 'secure' to true.
-	c.SetCookie("Token", sessionJWTString, int(app.JWTValidFor.Seconds()), "", "", false, true)
+	// Store session token in a cookie restricted by our security settings.
+	app.SetCookie(c, "Token", sessionJWTString, int(app.JWTValidFor.Seconds()), "/", app.CookieSameSite)
 }
 
 // Authorize takes a supplied request, extracts the to-
 // be-included JWT out of the set cookies and validates
 // it on various aspects. Additionally, it is checked that
-// the user has at least the required minimum privilege
-// specified by the calling handler.
-func (app *App) Authorize(Request *http.Request, MinimumPrivilege int) (*db.User, error) {
+// one of the user's roles grants the permission required
+// by the calling handler. An empty permission only requires
+// the user to be logged in.
+func (app *App) Authorize(Request *http.Request, Permission string) (*db.User, error) {
 
 	// Extract cookie with token from request.
 	cookie, err := Request.Cookie("Token")
@@ -100,13 +105,81 @@ func (app *App) Authorize(Request *http.Request, MinimumPrivilege int) (*db.User
 	}
 
 	var User db.User
-	app.DB.First(&User, "\"mail\" = ?", userMail)
+	app.DB.Preload("Roles").First(&User, "\"mail\" = ? AND \"enabled\" = ?", userMail, true)
+
+	// Session might belong to a meanwhile deactivated account.
+	if User.ID == "" {
+		return nil, errors.New("Authorization not present or correct. Please log in.")
+	}
 
 	// Check if logged-in user is allowed to view page.
-	if User.Privileges > MinimumPrivilege {
+	if (Permission != "") && !User.Can(Permission) {
 		return nil, errors.New("You do not have sufficient privileges.")
 	}
 
 	// We found the logged-in user.
 	return &User, nil
 }
+
+// ProvisionUser creates a new, enabled account for a user
+// that was authenticated by an external identity provider
+// but is not yet known to MODULIST. The account receives a
+// random password hash that is never handed out, the same
+// way accounts created by an admin start out.
+func (app *App) ProvisionUser(FirstName string, LastName string, Mail string, RoleNames []string) (*db.User, error) {
+
+	var NewUser db.User
+
+	NewUser.ID = fmt.Sprintf("%s", uuid.NewV4())
+	NewUser.FirstName = FirstName
+	NewUser.LastName = LastName
+	NewUser.Mail = Mail
+	NewUser.MailVerified = true
+	NewUser.StatusGroup = db.STATUS_GROUP_OTHER
+	NewUser.Privileges = db.PRIVILEGE_MIGRATED
+	NewUser.Roles = db.FindRoles(app.DB, RoleNames)
+	NewUser.Enabled = true
+
+	// Generate random bytes to derive the unused password from.
+	randomBytes := make([]byte, 24)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		log.Printf("[ProvisionUser] Generating random bytes for temporary user password went wrong: %s.\n", err.Error())
+
+		return nil, errors.New("Could not provision new user.")
+	}
+
+	// Generate a secure bcrypt hash from generated random bytes.
+	hash, err := bcrypt.GenerateFromPassword([]byte(fmt.Sprintf("%x", randomBytes)), app.HashCost)
+	if err != nil {
+		log.Printf("[ProvisionUser] Creating bcrypt password hash went wrong: %s.\n", err.Error())
+
+		return nil, errors.New("Could not provision new user.")
+	}
+	NewUser.PasswordHash = string(hash)
+
+	// Save new user to database.
+	if err := app.DB.Create(&NewUser).Error; err != nil {
+		log.Printf("[ProvisionUser] Saving provisioned user '%s' failed: %s.\n", Mail, err.Error())
+
+		return nil, errors.New("Could not provision new user.")
+	}
+
+	return &NewUser, nil
+}
+
+// LandingPage returns the first page the supplied user
+// is allowed to see after logging in. Module owners
+// without further permissions end up at their modules.
+func LandingPage(User db.User) string {
+
+	if User.Can(db.PERMISSION_READ) {
+		return "/modules"
+	}
+
+	if User.Can(db.PERMISSION_OWN_MODULES) {
+		return "/owner"
+	}
+
+	return "/settings"
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/freitagsrunde/modulist/db"
+	"github.com/jinzhu/gorm"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Variables
+
+// ErrInvalidCredentials is returned by an Authenticator if
+// mail and password did not identify an enabled account.
+var ErrInvalidCredentials = errors.New("Mail and/or password incorrect.")
+
+// Structs
+
+// Authenticator checks mail and password supplied by the
+// login form and returns the matching MODULIST account.
+type Authenticator interface {
+	Authenticate(Mail string, Password string) (*db.User, error)
+}
+
+// LocalAuthenticator compares the supplied password
+// against the bcrypt hash stored in MODULIST's database.
+type LocalAuthenticator struct {
+	DB *gorm.DB
+}
+
+// ChainAuthenticator asks each of its authenticators in
+// turn and accepts the first successful authentication.
+type ChainAuthenticator []Authenticator
+
+// Functions
+
+// Authenticate implements Authenticator for local accounts.
+func (local *LocalAuthenticator) Authenticate(Mail string, Password string) (*db.User, error) {
+
+	var User db.User
+	local.DB.Preload("Roles").First(&User, "\"mail\" = ? AND \"enabled\" = ?", Mail, true)
+
+	// Compare password hash from database with possible plaintext
+	// password from submitted login form. Compares in constant time.
+	err := bcrypt.CompareHashAndPassword([]byte(User.PasswordHash), []byte(Password))
+	if (User.ID == "") || (err != nil) {
+		return nil, ErrInvalidCredentials
+	}
+
+	return &User, nil
+}
+
+// Authenticate implements Authenticator by trying
+// all chained authenticators in configured order.
+func (chain ChainAuthenticator) Authenticate(Mail string, Password string) (*db.User, error) {
+
+	for _, authenticator := range chain {
+
+		User, err := authenticator.Authenticate(Mail, Password)
+		if err == nil {
+			return User, nil
+		}
+
+		// Report failures other than wrong credentials to the log.
+		if err != ErrInvalidCredentials {
+			log.Printf("[ChainAuthenticator] Authentication backend failed: %s.\n", err.Error())
+		}
+	}
+
+	return nil, ErrInvalidCredentials
+}
+
+// InitAuthenticator builds the chain of authentication
+// backends listed in APP_AUTH_BACKENDS of the .env file.
+func (app *App) InitAuthenticator() Authenticator {
+
+	backends := os.Getenv("APP_AUTH_BACKENDS")
+	if backends == "" {
+		backends = "local"
+	}
+
+	var chain ChainAuthenticator
+
+	for _, backend := range strings.Split(backends, ",") {
+
+		switch strings.TrimSpace(backend) {
+		case "local":
+			chain = append(chain, &LocalAuthenticator{DB: app.DB})
+		case "ldap":
+			chain = append(chain, app.InitLDAPAuthenticator())
+		default:
+			log.Fatalf("[InitAuthenticator] Unknown authentication backend '%s' in APP_AUTH_BACKENDS. Terminating.", backend)
+		}
+	}
+
+	return chain
+}
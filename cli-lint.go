@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/freitagsrunde/modulist/db"
+	"github.com/freitagsrunde/modulist/lint"
+	"github.com/joho/godotenv"
+)
+
+// Constants
+
+const (
+	// Exit codes of 'modulist lint'.
+	LINT_EXIT_OK       = 0
+	LINT_EXIT_FINDINGS = 1
+	LINT_EXIT_USAGE    = 2
+)
+
+// Functions
+
+// RunLint runs all plausibility checks over the whole
+// catalogue and prints the findings. The returned exit
+// code tells whether any finding reached the severity
+// threshold. Only the database settings of the .env
+// file are needed.
+func RunLint(Args []string) int {
+
+	Flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	Format := Flags.String("format", "table", "Output format of findings: 'table', 'json' or 'csv'.")
+	FailOn := Flags.String("fail-on", db.SEVERITY_MAJOR, "Exit with status 1 if a finding is at least this severe: 'hint', 'minor', 'major', 'blocking' or 'none'.")
+
+	if err := Flags.Parse(Args); err != nil {
+		return LINT_EXIT_USAGE
+	}
+
+	if (*FailOn != "none") && (lint.SeverityRank(*FailOn) < 0) {
+		fmt.Fprintf(os.Stderr, "Unknown severity '%s' for -fail-on.\n", *FailOn)
+
+		return LINT_EXIT_USAGE
+	}
+
+	var Write func(io.Writer, []lint.ModuleReport) error
+
+	switch *Format {
+	case "table":
+		Write = lint.WriteTable
+	case "json":
+		Write = lint.WriteJSON
+	case "csv":
+		Write = lint.WriteCSV
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown output format '%s'.\n", *Format)
+
+		return LINT_EXIT_USAGE
+	}
+
+	// Load .env configuration file for database settings.
+	if err := godotenv.Load(); err != nil {
+		log.Fatal("[RunLint] Failed to load .env file. Terminating.")
+	}
+
+	DB := db.InitDB()
+	defer DB.Close()
+
+	Reports := lint.CheckAll(db.LoadCatalogue(DB))
+
+	if err := Write(os.Stdout, Reports); err != nil {
+		log.Fatalf("[RunLint] Writing findings failed: %s.\n", err.Error())
+	}
+
+	if lint.Exceeds(Reports, *FailOn) {
+		return LINT_EXIT_FINDINGS
+	}
+
+	return LINT_EXIT_OK
+}
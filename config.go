@@ -6,9 +6,15 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"net/http"
+	"net/url"
+
 	"github.com/freitagsrunde/modulist/db"
+	"github.com/freitagsrunde/modulist/linkcheck"
+	"github.com/freitagsrunde/modulist/similarity"
 	"github.com/gin-gonic/gin"
 	"github.com/howeyc/gopass"
 	"github.com/jinzhu/gorm"
@@ -23,17 +29,32 @@ import (
 // App struct contains all relevant information read
 // from .env file and pointers to connectors of middleware.
 type App struct {
-	TLS         bool
-	TLSCertFile string
-	TLSKeyFile  string
-	IP          string
-	Port        string
-	Stage       string
-	HashCost    int
-	JWTValidFor time.Duration
-	Router      *gin.Engine
-	DB          *gorm.DB
-	Validator   *validator.Validate
+	TLS               bool
+	TLSCertFile       string
+	TLSKeyFile        string
+	IP                string
+	Port              string
+	RedirectPort      string
+	CookieSecure      bool
+	CookieSameSite    http.SameSite
+	CSP               string
+	HSTSMaxAge        int
+	Stage             string
+	BaseURL           string
+	HashCost          int
+	JWTValidFor       time.Duration
+	DigestInterval    time.Duration
+	LinkCheckInterval time.Duration
+	LinkChecker       *linkcheck.Checker
+	Router            *gin.Engine
+	DB                *gorm.DB
+	Validator         *validator.Validate
+	OIDC              *OIDCLogin
+	Authenticator     Authenticator
+	Mailer            Mailer
+	Hub               *Hub
+	Presence          *PresenceRegistry
+	Similarity        *similarity.Index
 }
 
 // Functions
@@ -45,19 +66,41 @@ func (app *App) DefineRoutes() {
 	// Route 'index'.
 	app.Router.GET("/", app.Index)
 	app.Router.POST("/", app.Login)
-	app.Router.GET("/logout", app.Logout)
+	app.Router.POST("/logout", app.Logout)
+	app.Router.GET("/login/oidc", app.OIDCRedirect)
+	app.Router.GET("/login/oidc/callback", app.OIDCCallback)
+	app.Router.GET("/login/link", app.LoginLinkView)
+	app.Router.POST("/login/link", app.RequestLoginLink)
+	app.Router.GET("/login/link/:secretToken", app.ConfirmLoginLink)
+	app.Router.POST("/login/link/:secretToken", app.UseLoginLink)
 
 	// Route 'list'.
 	app.Router.GET("/modules", app.ListModules)
 	app.Router.GET("/modules/search", app.SearchModules)
 	app.Router.GET("/modules/filter/:firstLetter", app.FilterModulesByLetter)
-	app.Router.GET("/modules/done/:id", app.MarkModuleDone)
+	app.Router.POST("/modules/done/:id", app.MarkModuleDone)
+	app.Router.GET("/modules/presence", app.ListPresence)
 
 	// Route 'feedback'.
 	app.Router.GET("/review/module/:moduleID", app.ReviewModule)
 	app.Router.POST("/review/module/:moduleID/add", app.AddFeedback)
-	app.Router.GET("/review/module/:moduleID/delete/:id", app.DeleteFeedback)
+	app.Router.POST("/review/module/:moduleID/delete/:id", app.DeleteFeedback)
+	app.Router.POST("/review/module/:moduleID/consensus/:id", app.MarkConsensus)
+	app.Router.POST("/review/module/:moduleID/classify/:id", app.ClassifyFeedback)
+	app.Router.POST("/review/module/:moduleID/vote/:id", app.VoteFeedback)
 	app.Router.GET("/review/module/:moduleID/comments", app.ListFeedback)
+	app.Router.GET("/review/module/:moduleID/events", app.StreamFeedback)
+	app.Router.GET("/review/module/:moduleID/workload", app.ModuleWorkload)
+	app.Router.GET("/review/module/:moduleID/prerequisites/:format", app.ModulePrerequisites)
+	app.Router.POST("/review/module/:moduleID/presence", app.PresenceHeartbeat)
+	app.Router.POST("/review/module/:moduleID/leave", app.LeaveModule)
+	app.Router.POST("/review/module/:moduleID/watch", app.WatchModule)
+
+	// Route 'course'.
+	app.Router.GET("/courses", app.ListCourses)
+	app.Router.GET("/review/course/:courseID", app.ViewCourse)
+	app.Router.POST("/review/course/:courseID/feedback", app.AddCourseFeedback)
+	app.Router.POST("/review/course/:courseID/feedback/delete/:id", app.DeleteCourseFeedback)
 
 	// Route 'settings'.
 	app.Router.GET("/settings", app.ListSettings)
@@ -65,13 +108,37 @@ func (app *App) DefineRoutes() {
 	app.Router.GET("/settings/:secretToken", app.PasswordLinkView)
 	app.Router.POST("/settings/:secretToken", app.UsePasswordLink)
 
+	// Pages for module owners.
+	app.Router.GET("/owner", app.ListOwnedModules)
+	app.Router.GET("/owner/module/:moduleID", app.OwnerModuleFeedback)
+	app.Router.POST("/owner/module/:moduleID/reply/:id", app.ReplyToFeedback)
+	app.Router.POST("/owner/module/:moduleID/resolve/:id", app.ResolveFeedback)
+	app.Router.POST("/owner/module/:moduleID/watch", app.WatchOwnedModule)
+
+	// Route 'notifications'.
+	app.Router.GET("/notifications", app.ListNotifications)
+	app.Router.GET("/notifications/open/:id", app.OpenNotification)
+	app.Router.POST("/notifications/read", app.MarkNotificationsRead)
+	app.Router.POST("/notifications/settings", app.UpdateNotificationSettings)
+
 	// Route 'admin'.
 	app.Router.GET("/admin/users", app.ListUsers)
 	app.Router.POST("/admin/users", app.CreateUser)
-	app.Router.GET("/admin/users/deactivate/:id", app.DeactivateUser)
-	app.Router.GET("/admin/users/activate/:id", app.ActivateUser)
+	app.Router.POST("/admin/users/deactivate/:id", app.DeactivateUser)
+	app.Router.POST("/admin/users/activate/:id", app.ActivateUser)
+	app.Router.POST("/admin/users/roles/:id", app.UpdateUserRoles)
 	app.Router.GET("/admin/send-feedback", app.SendFeedback)
+	app.Router.POST("/admin/send-feedback", app.SendFeedbackMail)
 	app.Router.POST("/admin/send-feedback/:where", app.UpdateMailTemplate)
+	app.Router.GET("/admin/canned-comments", app.ListCannedComments)
+	app.Router.POST("/admin/canned-comments", app.CreateCannedComment)
+	app.Router.POST("/admin/canned-comments/update/:id", app.UpdateCannedComment)
+	app.Router.POST("/admin/canned-comments/delete/:id", app.DeleteCannedComment)
+	app.Router.GET("/admin/lint", app.ListLintReport)
+	app.Router.GET("/admin/prerequisites", app.ListPrerequisites)
+	app.Router.GET("/admin/prerequisites/graph/:format", app.CataloguePrerequisites)
+	app.Router.POST("/admin/prerequisites/rebuild", app.RebuildPrerequisites)
+	app.Router.GET("/admin/similarity", app.ListSimilarModules)
 
 	// Serve static files and HTML templates.
 	app.Router.Static("/static", "./static")
@@ -105,9 +172,19 @@ func InitApp() *App {
 	app.IP = os.Getenv("HTTP_IP")
 	app.Port = os.Getenv("HTTP_PORT")
 
+	// Load settings for cookies, headers and HTTPS redirect.
+	app.InitSecurity()
+
 	// Store stage mode the application is running in.
 	app.Stage = os.Getenv("DEPLOY_STAGE")
 
+	// Save public address of MODULIST used to build links in mails.
+	baseURL, err := url.Parse(os.Getenv("APP_BASE_URL"))
+	if (err != nil) || (baseURL.Scheme == "") || (baseURL.Host == "") {
+		log.Fatal("[InitApp] Could not parse APP_BASE_URL from .env file, expecting an absolute URL. Terminating.")
+	}
+	app.BaseURL = strings.TrimSuffix(baseURL.String(), "/")
+
 	// Save bcrypt hash cost from .env.
 	// In production, this value should be at least '16'.
 	app.HashCost, err = strconv.Atoi(os.Getenv("APP_PASSWORD_HASH_COST"))
@@ -122,6 +199,41 @@ func InitApp() *App {
 	}
 	app.JWTValidFor = time.Duration(validFor) * time.Minute
 
+	// Set interval in hours of mailing notification digests, '0' disables them.
+	digestInterval, err := strconv.Atoi(os.Getenv("APP_DIGEST_INTERVAL"))
+	if (err != nil) || (digestInterval < 0) {
+		log.Fatal("[InitApp] Could not load APP_DIGEST_INTERVAL from .env file. Missing or not a positive integer?")
+	}
+	app.DigestInterval = time.Duration(digestInterval) * time.Hour
+
+	// Set interval in hours of checking links in modules, '0' disables it.
+	linkCheckInterval, err := strconv.Atoi(os.Getenv("APP_LINKCHECK_INTERVAL"))
+	if (err != nil) || (linkCheckInterval < 0) {
+		log.Fatal("[InitApp] Could not load APP_LINKCHECK_INTERVAL from .env file. Missing or not a positive integer?")
+	}
+	app.LinkCheckInterval = time.Duration(linkCheckInterval) * time.Hour
+
+	// Limit requests of the link checker: how many run in parallel,
+	// how many seconds each may take and how many milliseconds
+	// lie at least between two requests to the same host.
+	linkCheckConcurrency, err := strconv.Atoi(os.Getenv("APP_LINKCHECK_CONCURRENCY"))
+	if (err != nil) || (linkCheckConcurrency < 1) {
+		log.Fatal("[InitApp] Could not load APP_LINKCHECK_CONCURRENCY from .env file. Missing or not a positive integer?")
+	}
+
+	linkCheckTimeout, err := strconv.Atoi(os.Getenv("APP_LINKCHECK_TIMEOUT"))
+	if (err != nil) || (linkCheckTimeout < 1) {
+		log.Fatal("[InitApp] Could not load APP_LINKCHECK_TIMEOUT from .env file. Missing or not a positive integer?")
+	}
+
+	linkCheckHostDelay, err := strconv.Atoi(os.Getenv("APP_LINKCHECK_HOST_DELAY"))
+	if (err != nil) || (linkCheckHostDelay < 0) {
+		log.Fatal("[InitApp] Could not load APP_LINKCHECK_HOST_DELAY from .env file. Missing or not a positive integer?")
+	}
+
+	app.LinkChecker = linkcheck.NewChecker(&http.Client{}, linkCheckConcurrency,
+		(time.Duration(linkCheckTimeout) * time.Second), (time.Duration(linkCheckHostDelay) * time.Millisecond))
+
 	// Before starting gin, check if we are running in
 	// production and do not want to log everything.
 	if app.Stage == "prod" {
@@ -131,12 +243,32 @@ func InitApp() *App {
 	// Fill router variable with default gin router.
 	app.Router = gin.Default()
 
+	// Add security headers to every response and require a
+	// valid CSRF token on all state-changing requests.
+	app.Router.Use(app.SecurityHeaders())
+	app.Router.Use(app.CSRF())
+
 	// Append database connection.
 	app.DB = db.InitDB()
 
 	// Initialize the validator instance to validate fields with tag 'validate'.
 	app.Validator = validator.New()
 
+	// Set up login via OpenID Connect, if configured.
+	app.OIDC = InitOIDC()
+
+	// Chain the configured password authentication backends.
+	app.Authenticator = app.InitAuthenticator()
+
+	// Connect to the mail server, if configured.
+	app.Mailer = InitMailer()
+
+	// Pass live updates on feedback to open module pages.
+	app.Hub = NewHub()
+
+	// Track who currently has which module open.
+	app.Presence = NewPresenceRegistry()
+
 	// Register frontend routes.
 	app.DefineRoutes()
 
@@ -157,6 +289,25 @@ func InitApp() *App {
 		db.TransferModuleCourses(app.DB, os.Getenv("MODULES_SQLITE_PATH"))
 		db.TransferWorkingEfforts(app.DB, os.Getenv("MODULES_SQLITE_PATH"))
 		db.TransferExamElements(app.DB, os.Getenv("MODULES_SQLITE_PATH"))
+	}
+
+	// Bring database schema of existing setups up to date
+	// and make sure default roles are available.
+	db.MigrateTables(app.DB)
+
+	if *initFlag {
+
+		// Derive prerequisites between the transferred modules.
+		if _, err := app.RebuildRequirements(); err != nil {
+			log.Fatalf("[InitApp] Extracting requirements between modules failed: %s. Terminating.", err.Error())
+		}
+	}
+
+	// Index module texts to find content copied between modules.
+	// Modules only change when initializing, so this is done once.
+	app.Similarity = app.BuildSimilarityIndex()
+
+	if *initFlag {
 
 		// Default admin user creation.
 		fmt.Printf("\n\n\n========== Begin initializing MODULIST ==========\n\nCreate default admin user.\n")
@@ -204,8 +355,9 @@ func InitApp() *App {
 
 		fmt.Print("Done!\n\n")
 
-		// Give this user admin permissions.
-		NewAdmin.Privileges = db.PRIVILEGE_ADMIN
+		// Give this user the admin role.
+		NewAdmin.Privileges = db.PRIVILEGE_MIGRATED
+		NewAdmin.Roles = db.FindRoles(app.DB, []string{db.ROLE_ADMIN})
 
 		// Set account to enabled, initially.
 		NewAdmin.Enabled = true
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"log"
+
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Constants
+
+const (
+	// Names under which the CSRF token travels between
+	// server and browser: as cookie, as hidden field in
+	// HTML forms and as header in AJAX requests.
+	CSRF_COOKIE_NAME = "CSRFToken"
+	CSRF_FORM_FIELD  = "csrf-token"
+	CSRF_HEADER      = "X-CSRF-Token"
+
+	// Key under which the token is stored in gin's context.
+	CSRF_CONTEXT_KEY = "CSRFToken"
+)
+
+// Functions
+
+// CSRF returns a middleware protecting all state-changing
+// requests against cross-site request forgery. Each browser
+// session receives a random token in a cookie. Requests using
+// methods other than GET, HEAD and OPTIONS have to echo that
+// token back in a form field or header, which a foreign site
+// is not able to do.
+func (app *App) CSRF() gin.HandlerFunc {
+
+	return func(c *gin.Context) {
+
+		// Load token of this session, if already present.
+		sessionToken := ""
+		if cookie, err := c.Request.Cookie(CSRF_COOKIE_NAME); err == nil {
+			sessionToken = cookie.Value
+		}
+
+		// Issue a new token for sessions without one.
+		token := sessionToken
+		if token == "" {
+
+			var err error
+			token, err = RandomURLString(32)
+			if err != nil {
+				log.Printf("[CSRF] Generating CSRF token failed: %s.\n", err.Error())
+				c.AbortWithStatus(http.StatusInternalServerError)
+
+				return
+			}
+
+			app.SetCookie(c, CSRF_COOKIE_NAME, token, 0, "/", app.CookieSameSite)
+		}
+
+		// Make token available to templates.
+		c.Set(CSRF_CONTEXT_KEY, token)
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+
+			return
+		}
+
+		// State-changing request, the supplied token has to
+		// match the one already stored in the session cookie.
+		suppliedToken := c.Request.Header.Get(CSRF_HEADER)
+		if suppliedToken == "" {
+			suppliedToken = c.PostForm(CSRF_FORM_FIELD)
+		}
+
+		if (sessionToken == "") || (subtle.ConstantTimeCompare([]byte(suppliedToken), []byte(sessionToken)) != 1) {
+
+			log.Printf("[CSRF] Rejected %s request to '%s' without valid CSRF token.\n", c.Request.Method, c.Request.URL.Path)
+
+			c.JSON(http.StatusForbidden, gin.H{
+				"Reason": "Missing or invalid CSRF token. Please reload the page and try again.",
+			})
+			c.Abort()
+
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RenderHTML renders the named template like gin's
+// c.HTML but additionally passes what the shared
+// templates need: the CSRF token of the current session,
+// embedded into forms and pages, and the number of unread
+// notifications shown in the navigation bar.
+func (app *App) RenderHTML(c *gin.Context, Code int, Name string, Data gin.H) {
+
+	if token, exists := c.Get(CSRF_CONTEXT_KEY); exists {
+		Data["CSRFToken"] = token
+	}
+
+	app.addUnreadNotifications(Data)
+
+	c.HTML(Code, Name, Data)
+}
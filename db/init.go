@@ -64,24 +64,65 @@ func SetUpTables(db *gorm.DB) {
 
 	// Delete all tables corresponding to models if they exist.
 	db.DropTableIfExists(&User{})
+	db.DropTableIfExists(&Role{})
+	db.DropTableIfExists("user_roles")
 	db.DropTableIfExists(&PasswordLink{})
+	db.DropTableIfExists(&LoginLink{})
 	db.DropTableIfExists(&Module{})
 	db.DropTableIfExists(&Person{})
 	db.DropTableIfExists(&Course{})
 	db.DropTableIfExists(&WorkingEffort{})
 	db.DropTableIfExists(&ExamElement{})
 	db.DropTableIfExists(&Feedback{})
+	db.DropTableIfExists(&FeedbackVote{})
+	db.DropTableIfExists(&MailTemplate{})
+	db.DropTableIfExists(&CannedComment{})
+	db.DropTableIfExists(&Notification{})
+	db.DropTableIfExists(&Watch{})
+	db.DropTableIfExists(&ModuleEvent{})
+	db.DropTableIfExists(&ModuleRequirement{})
+	db.DropTableIfExists(&LinkStatus{})
+	db.DropTableIfExists(&CourseFeedback{})
 	db.DropTableIfExists("module_courses")
 
 	// Create new ones for all models.
+	db.CreateTable(&Role{})
 	db.CreateTable(&User{})
 	db.CreateTable(&PasswordLink{})
+	db.CreateTable(&LoginLink{})
 	db.CreateTable(&Module{})
 	db.CreateTable(&Person{})
 	db.CreateTable(&Course{})
 	db.CreateTable(&WorkingEffort{})
 	db.CreateTable(&ExamElement{})
 	db.CreateTable(&Feedback{})
+	db.CreateTable(&FeedbackVote{})
+	db.CreateTable(&MailTemplate{})
+	db.CreateTable(&CannedComment{})
+	db.CreateTable(&Notification{})
+	db.CreateTable(&Watch{})
+	db.CreateTable(&ModuleEvent{})
+	db.CreateTable(&ModuleRequirement{})
+	db.CreateTable(&LinkStatus{})
+	db.CreateTable(&CourseFeedback{})
+}
+
+// MigrateTables brings the schema of an existing database
+// up to date without deleting any data. Missing tables and
+// columns are added, default roles created and accounts
+// from before the introduction of roles migrated.
+func MigrateTables(db *gorm.DB) {
+
+	// Add missing tables and columns for all models.
+	db.AutoMigrate(&Role{}, &User{}, &PasswordLink{}, &LoginLink{}, &Module{}, &Person{},
+		&Course{}, &WorkingEffort{}, &ExamElement{}, &Feedback{}, &FeedbackVote{}, &MailTemplate{}, &CannedComment{}, &Notification{},
+		&Watch{}, &ModuleEvent{}, &ModuleRequirement{}, &LinkStatus{}, &CourseFeedback{})
+
+	// Make sure default roles are available.
+	SeedRoles(db)
+
+	// Convert former privileges to roles.
+	MigrateUserRoles(db)
 }
 
 // TransferPersons connects to the provided SQLite database
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// Structs
+
+// CannedComment is a reusable snippet of feedback on
+// one category, describing a problem many modules share.
+// Reviewers insert it into their comment and feedback
+// created from it references it to count its usage.
+type CannedComment struct {
+	ID       int    `gorm:"primary_key"`
+	Category int    `gorm:"index;not null"`
+	Title    string `gorm:"not null"`
+	Comment  string `gorm:"not null"`
+}
+
+// CannedCommentUsage counts how often a canned comment
+// was used and on how many different modules.
+type CannedCommentUsage struct {
+	CannedCommentID int
+	Comments        int
+	Modules         int
+}
+
+// Functions
+
+// ListCannedComments loads all canned comments
+// ordered by category and title.
+func ListCannedComments(db *gorm.DB) []CannedComment {
+
+	var Comments []CannedComment
+	db.Order("\"category\" asc").Order("\"title\" asc").Find(&Comments)
+
+	return Comments
+}
+
+// CannedCommentsByCategory groups all canned
+// comments by the category they belong to.
+func CannedCommentsByCategory(db *gorm.DB) map[int][]CannedComment {
+
+	ByCategory := make(map[int][]CannedComment)
+
+	for _, Comment := range ListCannedComments(db) {
+		ByCategory[Comment.Category] = append(ByCategory[Comment.Category], Comment)
+	}
+
+	return ByCategory
+}
+
+// LoadCannedCommentUsage counts the feedback created from
+// each canned comment, mapped to the canned comment's ID.
+func LoadCannedCommentUsage(db *gorm.DB) map[int]CannedCommentUsage {
+
+	var Rows []CannedCommentUsage
+	db.Model(&Feedback{}).
+		Select("\"canned_comment_id\", count(*) AS \"comments\", count(DISTINCT \"module_id\") AS \"modules\"").
+		Where("\"canned_comment_id\" <> ?", 0).
+		Group("\"canned_comment_id\"").
+		Scan(&Rows)
+
+	Usage := make(map[int]CannedCommentUsage)
+	for _, Row := range Rows {
+		Usage[Row.CannedCommentID] = Row
+	}
+
+	return Usage
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"time"
+
+	"html/template"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Structs
+
+// CourseFeedback is a comment on a course. As courses
+// may be part of several modules, such a comment
+// applies to every module including the course and is
+// displayed along with the feedback on each of them.
+// Comments are written in a subset of Markdown,
+// CommentHTML holds the sanitized rendering for display.
+// Course is loaded separately, gorm would take the
+// course number Course.CourseID for the foreign key.
+type CourseFeedback struct {
+	ID          int           `gorm:"primary_key"`
+	CourseID    int           `gorm:"index;not null"`
+	Course      Course        `gorm:"-"`
+	UserID      string        `gorm:"index;not null"`
+	Comment     string        `gorm:"not null"`
+	CommentHTML template.HTML `gorm:"-"`
+	Severity    string        `gorm:"not null;default:'minor'"`
+	Created     time.Time     `gorm:"not null"`
+}
+
+// Functions
+
+// ListCourseFeedback loads all comments on the courses
+// with supplied IDs including their course, oldest first.
+func ListCourseFeedback(db *gorm.DB, CourseIDs ...int) []CourseFeedback {
+
+	Feedback := []CourseFeedback{}
+
+	if len(CourseIDs) > 0 {
+		db.Where("\"course_id\" IN (?)", CourseIDs).
+			Order("\"created\" asc").Order("\"id\" asc").Find(&Feedback)
+
+		var Courses []Course
+		db.Where("\"id\" IN (?)", CourseIDs).Find(&Courses)
+
+		CoursesByID := make(map[int]Course)
+		for _, Course := range Courses {
+			CoursesByID[Course.ID] = Course
+		}
+
+		for i := range Feedback {
+			Feedback[i].Course = CoursesByID[Feedback[i].CourseID]
+		}
+	}
+
+	return Feedback
+}
+
+// CommentedCourseIDs returns the IDs of all
+// courses with at least one comment.
+func CommentedCourseIDs(db *gorm.DB) []int {
+
+	var IDs []int
+	db.Model(&CourseFeedback{}).Pluck("DISTINCT \"course_id\"", &IDs)
+
+	return IDs
+}
+
+// ListCourseModules loads all modules including the
+// course with supplied ID, ordered by title.
+func ListCourseModules(db *gorm.DB, CourseID int) []Module {
+
+	var Modules []Module
+	db.Joins("JOIN \"module_courses\" ON \"module_courses\".\"module_id\" = \"modules\".\"id\"").
+		Where("\"module_courses\".\"course_id\" = ?", CourseID).
+		Order("\"title\" asc").Order("\"version\" desc").Find(&Modules)
+
+	return Modules
+}
+
+// CourseIDs returns the IDs of the courses of supplied
+// module, which have to be loaded for this.
+func (module Module) CourseIDs() []int {
+
+	IDs := make([]int, len(module.Courses))
+	for i, Course := range module.Courses {
+		IDs[i] = Course.ID
+	}
+
+	return IDs
+}
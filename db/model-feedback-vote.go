@@ -0,0 +1,104 @@
+package db
+
+import (
+	"sort"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Structs
+
+// FeedbackVote records that a reviewer agrees with a
+// top-level comment of another reviewer. Each reviewer
+// votes at most once per comment.
+type FeedbackVote struct {
+	FeedbackID int    `gorm:"primary_key;auto_increment:false"`
+	UserID     string `gorm:"primary_key"`
+}
+
+// voteCount is one row of aggregated votes.
+type voteCount struct {
+	FeedbackID  int
+	StatusGroup int
+	Votes       int
+}
+
+// Functions
+
+// LoadVotes counts the votes on all supplied discussions,
+// in total and per status group of the voters, and marks
+// the ones the user with supplied ID voted for.
+func LoadVotes(db *gorm.DB, Threads []Feedback, UserID string) {
+
+	if len(Threads) == 0 {
+		return
+	}
+
+	IDs := make([]int, len(Threads))
+	for i, Thread := range Threads {
+		IDs[i] = Thread.ID
+	}
+
+	var Counts []voteCount
+	db.Table("feedback_votes").
+		Select("\"feedback_votes\".\"feedback_id\", \"users\".\"status_group\", count(*) AS \"votes\"").
+		Joins("JOIN \"users\" ON \"users\".\"id\" = \"feedback_votes\".\"user_id\"").
+		Where("\"feedback_votes\".\"feedback_id\" IN (?)", IDs).
+		Group("\"feedback_votes\".\"feedback_id\", \"users\".\"status_group\"").
+		Scan(&Counts)
+
+	var Voted []int
+	db.Model(&FeedbackVote{}).Where("\"feedback_id\" IN (?) AND \"user_id\" = ?", IDs, UserID).Pluck("\"feedback_id\"", &Voted)
+
+	for i := range Threads {
+
+		Threads[i].VotesByGroup = make(map[int]int)
+
+		for _, Count := range Counts {
+
+			if Count.FeedbackID == Threads[i].ID {
+				Threads[i].Votes += Count.Votes
+				Threads[i].VotesByGroup[Count.StatusGroup] += Count.Votes
+			}
+		}
+
+		for _, ID := range Voted {
+
+			if ID == Threads[i].ID {
+				Threads[i].Voted = true
+			}
+		}
+	}
+}
+
+// SortBySupport orders discussions by module and
+// category like ListThreads, but within a category
+// the comments with most votes come first.
+func SortBySupport(Threads []Feedback) {
+
+	sort.SliceStable(Threads, func(i, j int) bool {
+
+		if Threads[i].ModuleID != Threads[j].ModuleID {
+			return Threads[i].ModuleID < Threads[j].ModuleID
+		}
+
+		if Threads[i].Category != Threads[j].Category {
+			return Threads[i].Category < Threads[j].Category
+		}
+
+		return Threads[i].Votes > Threads[j].Votes
+	})
+}
+
+// ToggleVote adds the vote of supplied user on a comment
+// or withdraws it again, if the user already voted.
+func ToggleVote(db *gorm.DB, FeedbackID int, UserID string) {
+
+	var Vote FeedbackVote
+
+	if db.First(&Vote, "\"feedback_id\" = ? AND \"user_id\" = ?", FeedbackID, UserID).RecordNotFound() {
+		db.Create(&FeedbackVote{FeedbackID: FeedbackID, UserID: UserID})
+	} else {
+		db.Delete(FeedbackVote{}, "\"feedback_id\" = ? AND \"user_id\" = ?", FeedbackID, UserID)
+	}
+}
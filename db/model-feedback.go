@@ -1,5 +1,17 @@
 package db
 
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"crypto/sha256"
+	"html/template"
+
+	"github.com/freitagsrunde/modulist/diff"
+	"github.com/jinzhu/gorm"
+)
+
 // Constants
 
 const (
@@ -23,14 +35,113 @@ const (
 	CATEGORY_MISCELLANEOUS
 )
 
+const (
+	// Plain comments only carry text, suggestions
+	// additionally propose a replacement text for
+	// a free text field of the module.
+	KIND_COMMENT    = "comment"
+	KIND_SUGGESTION = "suggestion"
+
+	// How severe the problem a comment points out is.
+	SEVERITY_HINT     = "hint"
+	SEVERITY_MINOR    = "minor"
+	SEVERITY_MAJOR    = "major"
+	SEVERITY_BLOCKING = "blocking"
+
+	// How a comment was dealt with. Moderators accept or
+	// reject comments, module owners resolve them.
+	STATUS_OPEN     = "open"
+	STATUS_ACCEPTED = "accepted"
+	STATUS_REJECTED = "rejected"
+	STATUS_RESOLVED = "resolved"
+
+	// Amount of suggestion diffs kept in memory.
+	MAX_CACHED_DIFFS = 2000
+)
+
+// Variables
+
+// suggestionDiffs keeps the comparisons of suggestions, as
+// each page view and mail would repeat them otherwise. It
+// is emptied once it holds MAX_CACHED_DIFFS entries.
+var suggestionDiffs = struct {
+	lock    sync.Mutex
+	entries map[suggestionDiffKey][]diff.Segment
+}{
+	entries: make(map[suggestionDiffKey][]diff.Segment),
+}
+
 // Structs
 
+// Feedback is a comment on one category of a module.
+// Replies share module and category of their comment and
+// reference it via ParentID, which is 0 for top-level
+// comments. Fields without column are set for display.
 type Feedback struct {
 	ID       int    `gorm:"primary_key"`
 	ModuleID int    `gorm:"index;not null"`
 	UserID   string `gorm:"index;not null"`
 	Category int    `gorm:"not null"`
-	Comment  string `gorm:"not null"`
+
+	// Written in a subset of Markdown, CommentHTML
+	// holds the sanitized rendering.
+	Comment     string        `gorm:"not null"`
+	CommentHTML template.HTML `gorm:"-"`
+
+	// A reply may be the consensus a discussion resolved into.
+	ParentID  int  `gorm:"index;not null;default:0"`
+	Consensus bool `gorm:"not null;default:false"`
+
+	// Classification, Tags are a normalized, comma-separated list.
+	Severity string `gorm:"not null;default:'minor'"`
+	Status   string `gorm:"index;not null;default:'open'"`
+	Tags     string `gorm:"not null;default:''"`
+
+	// Passage of a free text field the comment refers to, as
+	// offsets in UTF-16 code units and the quoted text. AnchorFound
+	// tells whether the passage is still part of the field.
+	AnchorField string `gorm:"not null;default:''"`
+	AnchorStart int    `gorm:"not null;default:0"`
+	AnchorEnd   int    `gorm:"not null;default:0"`
+	AnchorQuote string `gorm:"not null;default:''"`
+	AnchorFound bool   `gorm:"-"`
+
+	// Canned comment the comment was started from, if any.
+	CannedCommentID int `gorm:"index;not null;default:0"`
+
+	// Suggestions keep the text of the field at the time of
+	// suggesting next to the proposal. SuggestionOutdated
+	// tells whether the field has changed since.
+	Kind               string         `gorm:"not null;default:'comment'"`
+	SuggestionField    string         `gorm:"not null;default:''"`
+	SuggestionOriginal string         `gorm:"not null;default:''"`
+	Suggestion         string         `gorm:"not null;default:''"`
+	SuggestionOutdated bool           `gorm:"-"`
+	Diff               []diff.Segment `gorm:"-"`
+
+	// Reviewers agreeing with the comment.
+	Votes        int         `gorm:"-"`
+	VotesByGroup map[int]int `gorm:"-"`
+	Voted        bool        `gorm:"-"`
+
+	Replies []Feedback `gorm:"ForeignKey:ParentID"`
+}
+
+// suggestionDiffKey identifies the comparison of one
+// suggestion by the hash of the compared texts.
+type suggestionDiffKey struct {
+	FeedbackID int
+	Texts      [sha256.Size]byte
+}
+
+// FeedbackFilter selects comments by their classification.
+// Empty fields do not restrict the selection.
+// Votes have to be loaded for MinVotes to apply.
+type FeedbackFilter struct {
+	Severities []string
+	Statuses   []string
+	Tag        string
+	MinVotes   int
 }
 
 // Functions
@@ -58,3 +169,215 @@ func CategoriesByName() map[string]int {
 
 	return Categories
 }
+
+// CategoryTitles returns a map of the titles
+// of all categories as displayed to users.
+func CategoryTitles() map[int]string {
+
+	Titles := make(map[int]string)
+
+	Titles[CATEGORY_HEADER] = "Modulkopf"
+	Titles[CATEGORY_LEARNING_OUTCOMES] = "Lernergebnisse"
+	Titles[CATEGORY_TEACHING_CONTENTS] = "Lehrinhalte"
+	Titles[CATEGORY_COURSES] = "Modulbestandteile"
+	Titles[CATEGORY_WORKING_EFFORT] = "Arbeitsaufwand und Leistungspunkte"
+	Titles[CATEGORY_INSTRUCTIVE_FORM] = "Beschreibung der Lehr- und Lernformen"
+	Titles[CATEGORY_REQUIREMENTS] = "Voraussetzungen für die Teilnahme / Prüfung"
+	Titles[CATEGORY_EXAMINATION] = "Abschluss des Moduls"
+	Titles[CATEGORY_NUMBER_TERMS] = "Dauer des Moduls"
+	Titles[CATEGORY_PARTICIPANT_LIMITATION] = "Maximale teilnehmende Personen"
+	Titles[CATEGORY_REGISTRATION_FORMALITIES] = "Anmeldeformalitäten"
+	Titles[CATEGORY_SCRIPT] = "Skript"
+	Titles[CATEGORY_LITERATURE] = "Literaturhinweise"
+	Titles[CATEGORY_MISCELLANEOUS] = "Sonstiges"
+
+	return Titles
+}
+
+// IsSuggestion reports whether this feedback proposes
+// a replacement text for a field of the module.
+func (feedback Feedback) IsSuggestion() bool {
+	return feedback.Kind == KIND_SUGGESTION
+}
+
+// SuggestionDiff compares the proposed text of a
+// suggestion word by word with the original text.
+// Segments are shared, callers must not modify them.
+func (feedback Feedback) SuggestionDiff() []diff.Segment {
+
+	Key := suggestionDiffKey{
+		FeedbackID: feedback.ID,
+		Texts:      sha256.Sum256([]byte(feedback.SuggestionOriginal + "\x00" + feedback.Suggestion)),
+	}
+
+	suggestionDiffs.lock.Lock()
+	Segments, ok := suggestionDiffs.entries[Key]
+	suggestionDiffs.lock.Unlock()
+
+	if ok {
+		return Segments
+	}
+
+	Segments = diff.Words(feedback.SuggestionOriginal, feedback.Suggestion)
+
+	suggestionDiffs.lock.Lock()
+	if len(suggestionDiffs.entries) >= MAX_CACHED_DIFFS {
+		suggestionDiffs.entries = make(map[suggestionDiffKey][]diff.Segment)
+	}
+	suggestionDiffs.entries[Key] = Segments
+	suggestionDiffs.lock.Unlock()
+
+	return Segments
+}
+
+// ConsensusReply returns the reply marked as consensus
+// of this discussion, if the discussion was resolved.
+// Replies have to be preloaded for this.
+func (feedback Feedback) ConsensusReply() (Feedback, bool) {
+
+	for _, reply := range feedback.Replies {
+
+		if reply.Consensus {
+			return reply, true
+		}
+	}
+
+	return Feedback{}, false
+}
+
+// ListThreads loads all top-level feedback on the modules
+// with supplied IDs, each with its replies in order.
+func ListThreads(db *gorm.DB, ModuleIDs ...int) []Feedback {
+
+	var Threads []Feedback
+
+	if len(ModuleIDs) > 0 {
+
+		db.Preload("Replies", func(scope *gorm.DB) *gorm.DB {
+			return scope.Order("\"id\" asc")
+		}).Order("\"module_id\" asc").Order("\"category\" asc").Order("\"id\" asc").
+			Find(&Threads, "\"module_id\" IN (?) AND \"parent_id\" = ?", ModuleIDs, 0)
+	}
+
+	return Threads
+}
+
+// Severities returns all severities, least severe first.
+func Severities() []string {
+	return []string{SEVERITY_HINT, SEVERITY_MINOR, SEVERITY_MAJOR, SEVERITY_BLOCKING}
+}
+
+// SeverityTitles returns a map of the titles
+// of all severities as displayed to users.
+func SeverityTitles() map[string]string {
+
+	return map[string]string{
+		SEVERITY_HINT:     "Hinweis",
+		SEVERITY_MINOR:    "Gering",
+		SEVERITY_MAJOR:    "Erheblich",
+		SEVERITY_BLOCKING: "Blockierend",
+	}
+}
+
+// Statuses returns all statuses in order of processing.
+func Statuses() []string {
+	return []string{STATUS_OPEN, STATUS_ACCEPTED, STATUS_REJECTED, STATUS_RESOLVED}
+}
+
+// StatusTitles returns a map of the titles
+// of all statuses as displayed to users.
+func StatusTitles() map[string]string {
+
+	return map[string]string{
+		STATUS_OPEN:     "Offen",
+		STATUS_ACCEPTED: "Angenommen",
+		STATUS_REJECTED: "Abgelehnt",
+		STATUS_RESOLVED: "Vom Fachgebiet erledigt",
+	}
+}
+
+// NormalizeTags turns a comma-separated list of free
+// tags into the stored form: trimmed, lower-case,
+// sorted and without empty or duplicate tags.
+func NormalizeTags(Tags string) string {
+
+	seen := make(map[string]bool)
+	normalized := []string{}
+
+	for _, tag := range strings.Split(Tags, ",") {
+
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if (tag == "") || seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	sort.Strings(normalized)
+
+	return strings.Join(normalized, ",")
+}
+
+// TagList returns the tags of this feedback.
+func (feedback Feedback) TagList() []string {
+
+	if feedback.Tags == "" {
+		return []string{}
+	}
+
+	return strings.Split(feedback.Tags, ",")
+}
+
+// contains reports whether Values contains Value.
+func contains(Values []string, Value string) bool {
+
+	for _, v := range Values {
+
+		if v == Value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Matches reports whether supplied feedback
+// is selected by this filter.
+func (filter FeedbackFilter) Matches(feedback Feedback) bool {
+
+	if (len(filter.Severities) > 0) && !contains(filter.Severities, feedback.Severity) {
+		return false
+	}
+
+	if (len(filter.Statuses) > 0) && !contains(filter.Statuses, feedback.Status) {
+		return false
+	}
+
+	if (filter.Tag != "") && !contains(feedback.TagList(), strings.ToLower(filter.Tag)) {
+		return false
+	}
+
+	if feedback.Votes < filter.MinVotes {
+		return false
+	}
+
+	return true
+}
+
+// FilterThreads returns the discussions whose top-level
+// comment is selected by supplied filter. Replies are
+// kept with their comment regardless of the filter.
+func FilterThreads(Threads []Feedback, Filter FeedbackFilter) []Feedback {
+
+	Filtered := []Feedback{}
+	for _, Thread := range Threads {
+
+		if Filter.Matches(Thread) {
+			Filtered = append(Filtered, Thread)
+		}
+	}
+
+	return Filtered
+}
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Structs
+
+// LinkStatus is the outcome of the last check of a link
+// found in a module or course. Status is the HTTP status
+// code received, 0 if the link could not be reached, in
+// which case Error tells why.
+type LinkStatus struct {
+	URL     string    `gorm:"primary_key"`
+	Status  int       `gorm:"not null;default:0"`
+	Error   string    `gorm:"not null;default:''"`
+	Checked time.Time `gorm:"not null"`
+}
+
+// Functions
+
+// Broken reports whether the link could not be reached
+// or answered with a client or server error.
+func (status LinkStatus) Broken() bool {
+	return (status.Error != "") || (status.Status >= 400)
+}
+
+// Describe tells in words why a link is broken.
+func (status LinkStatus) Describe() string {
+
+	if status.Error != "" {
+		return status.Error
+	}
+
+	return fmt.Sprintf("HTTP-Status %d", status.Status)
+}
+
+// Links returns the trimmed links of supplied module and
+// its courses, as far as they are loaded.
+func (module Module) Links() []string {
+
+	Links := []string{}
+
+	if module.Website.Valid {
+		Links = append(Links, module.Website.String)
+	}
+
+	Links = append(Links, module.URL)
+
+	for _, Course := range module.Courses {
+
+		if Course.CourseURL.Valid {
+			Links = append(Links, Course.CourseURL.String)
+		}
+	}
+
+	Trimmed := []string{}
+
+	for _, Link := range Links {
+
+		if Link = strings.TrimSpace(Link); Link != "" {
+			Trimmed = append(Trimmed, Link)
+		}
+	}
+
+	return Trimmed
+}
+
+// CollectLinks returns all links of modules and courses
+// of the catalogue, each once.
+func CollectLinks(db *gorm.DB) []string {
+
+	var Websites, URLs, CourseURLs []string
+	db.Model(&Module{}).Where("\"website\" IS NOT NULL AND \"website\" <> ''").Pluck("DISTINCT \"website\"", &Websites)
+	db.Model(&Module{}).Where("\"url\" <> ''").Pluck("DISTINCT \"url\"", &URLs)
+	db.Model(&Course{}).Where("\"course_url\" IS NOT NULL AND \"course_url\" <> ''").Pluck("DISTINCT \"course_url\"", &CourseURLs)
+
+	Links := []string{}
+	Seen := make(map[string]bool)
+	for _, Link := range append(append(Websites, URLs...), CourseURLs...) {
+
+		if Link = strings.TrimSpace(Link); (Link != "") && !Seen[Link] {
+			Seen[Link] = true
+			Links = append(Links, Link)
+		}
+	}
+
+	return Links
+}
+
+// SaveLinkStatuses stores supplied statuses, replacing
+// earlier ones, and forgets about links not part of them.
+func SaveLinkStatuses(db *gorm.DB, Statuses []LinkStatus) error {
+
+	tx := db.Begin()
+
+	Links := []string{}
+	for i := range Statuses {
+
+		if err := tx.Save(&Statuses[i]).Error; err != nil {
+			tx.Rollback()
+
+			return err
+		}
+
+		Links = append(Links, Statuses[i].URL)
+	}
+
+	Outdated := tx
+	if len(Links) > 0 {
+		Outdated = tx.Where("\"url\" NOT IN (?)", Links)
+	}
+
+	if err := Outdated.Delete(LinkStatus{}).Error; err != nil {
+		tx.Rollback()
+
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// LoadLinkStatuses loads the stored statuses of supplied
+// links, keyed by link. Unchecked links are missing.
+func LoadLinkStatuses(db *gorm.DB, Links []string) map[string]LinkStatus {
+
+	Statuses := make(map[string]LinkStatus)
+	if len(Links) == 0 {
+		return Statuses
+	}
+
+	var Found []LinkStatus
+	db.Where("\"url\" IN (?)", Links).Find(&Found)
+
+	for _, Status := range Found {
+		Statuses[Status.URL] = Status
+	}
+
+	return Statuses
+}
+
+// LoadAllLinkStatuses loads all stored statuses, keyed by link.
+func LoadAllLinkStatuses(db *gorm.DB) map[string]LinkStatus {
+
+	var Found []LinkStatus
+	db.Find(&Found)
+
+	Statuses := make(map[string]LinkStatus)
+	for _, Status := range Found {
+		Statuses[Status.URL] = Status
+	}
+
+	return Statuses
+}
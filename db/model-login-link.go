@@ -0,0 +1,19 @@
+package db
+
+import (
+	"time"
+)
+
+// Structs
+
+// LoginLink is a secret, short-lived link sent via mail
+// that logs in module owners without a password. It is
+// bound to a mail address instead of an account, because
+// owners are allowed to request a link before an account
+// for them exists.
+type LoginLink struct {
+	ID          string    `gorm:"primary_key"`
+	Mail        string    `gorm:"index;not null"`
+	SecretToken string    `gorm:"not null;unique"`
+	Expires     time.Time `gorm:"not null"`
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// Constants
+
+const (
+	// Names of the editable texts framing
+	// each mail containing feedback.
+	MAIL_TEMPLATE_HEADER = "mail-header"
+	MAIL_TEMPLATE_FOOTER = "mail-footer"
+)
+
+// Structs
+
+// MailTemplate stores a text admins can edit
+// that is used when composing mails.
+type MailTemplate struct {
+	Name    string `gorm:"primary_key"`
+	Content string `gorm:"not null"`
+}
+
+// Functions
+
+// LoadMailTemplate returns the content of the mail
+// template with supplied name or an empty string
+// if it was never saved.
+func LoadMailTemplate(db *gorm.DB, Name string) string {
+
+	var Template MailTemplate
+	db.First(&Template, "\"name\" = ?", Name)
+
+	return Template.Content
+}
+
+// SaveMailTemplate creates or updates the
+// mail template with supplied name.
+func SaveMailTemplate(db *gorm.DB, Name string, Content string) error {
+
+	return db.Save(&MailTemplate{Name: Name, Content: Content}).Error
+}
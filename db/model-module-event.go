@@ -0,0 +1,63 @@
+package db
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Constants
+
+const (
+	// What happened to feedback on a module.
+	EVENT_COMMENT   = "comment"
+	EVENT_REPLY     = "reply"
+	EVENT_STATUS    = "status"
+	EVENT_CONSENSUS = "consensus"
+)
+
+// Structs
+
+// ModuleEvent records that a user, the actor, commented on
+// a module, replied to a comment, changed the status of a
+// comment or marked a reply as consensus. Status holds the
+// new status of status changes. Events are summarized in
+// digests for users watching the module. Module is
+// loaded separately, see modulesByID.
+type ModuleEvent struct {
+	ID         int       `gorm:"primary_key"`
+	ModuleID   int       `gorm:"index;not null"`
+	Module     Module    `gorm:"-"`
+	ActorID    string    `gorm:"not null"`
+	Actor      User      `gorm:"ForeignKey:ActorID"`
+	FeedbackID int       `gorm:"index;not null"`
+	Feedback   Feedback  `gorm:"ForeignKey:FeedbackID"`
+	Kind       string    `gorm:"not null"`
+	Status     string    `gorm:"not null;default:''"`
+	Created    time.Time `gorm:"index;not null"`
+}
+
+// Functions
+
+// ListModuleEvents loads all events on the modules with
+// supplied IDs that happened after supplied time and were
+// not caused by the user with supplied ID, ordered by module
+// and time. Actor, module and feedback are included.
+func ListModuleEvents(db *gorm.DB, ModuleIDs []int, Since time.Time, ExceptUserID string) []ModuleEvent {
+
+	Events := []ModuleEvent{}
+
+	if len(ModuleIDs) > 0 {
+
+		db.Preload("Actor").Preload("Feedback").
+			Where("\"module_id\" IN (?) AND \"created\" > ? AND \"actor_id\" <> ?", ModuleIDs, Since, ExceptUserID).
+			Order("\"module_id\" asc").Order("\"created\" asc").Find(&Events)
+
+		Modules := modulesByID(db, ModuleIDs)
+		for i := range Events {
+			Events[i].Module = Modules[Events[i].ModuleID]
+		}
+	}
+
+	return Events
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// Structs
+
+// ModuleRequirement is a module named in the mandatory or
+// optional requirements of another module. Reference holds
+// the text naming it. RequiredModuleID is 0 if the text
+// names a module number not part of the catalogue.
+type ModuleRequirement struct {
+	ID               int    `gorm:"primary_key"`
+	ModuleID         int    `gorm:"index;not null"`
+	RequiredModuleID int    `gorm:"index;not null;default:0"`
+	Reference        string `gorm:"not null"`
+	Mandatory        bool   `gorm:"not null"`
+}
+
+// Functions
+
+// ListModuleRequirements loads all requirements
+// between modules of the catalogue.
+func ListModuleRequirements(db *gorm.DB) []ModuleRequirement {
+
+	var Requirements []ModuleRequirement
+	db.Order("\"module_id\" asc").Order("\"id\" asc").Find(&Requirements)
+
+	return Requirements
+}
+
+// ReplaceModuleRequirements deletes all stored
+// requirements and saves supplied ones instead.
+func ReplaceModuleRequirements(db *gorm.DB, Requirements []ModuleRequirement) error {
+
+	tx := db.Begin()
+
+	if err := tx.Delete(ModuleRequirement{}).Error; err != nil {
+		tx.Rollback()
+
+		return err
+	}
+
+	for i := range Requirements {
+
+		if err := tx.Create(&Requirements[i]).Error; err != nil {
+			tx.Rollback()
+
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"strings"
 	"time"
 
 	"database/sql"
@@ -60,7 +61,8 @@ type Module struct {
 	ReferencePersonID           sql.NullInt64
 	ReferencePerson             Person `gorm:"ForeignKey:ReferencePersonID;AssociationForeignKey:Refer;"`
 	ResponsiblePersonID         sql.NullInt64
-	ResponsiblePerson           Person `gorm:"ForeignKey:ResponsiblePersonID;AssociationForeignKey:Refer;"`
+	ResponsiblePerson           Person                `gorm:"ForeignKey:ResponsiblePersonID;AssociationForeignKey:Refer;"`
+	LinkStatuses                map[string]LinkStatus `gorm:"-"`
 }
 
 type SQLiteModule struct {
@@ -149,3 +151,160 @@ func (sqliteModule SQLiteModule) ToModule(db *gorm.DB) Module {
 		RegistrationFormalities: sqliteModule.RegistrationFormalities,
 	}
 }
+
+// ownedBy restricts a query on modules to the ones
+// owned by supplied user. A user owns all modules
+// listing the user's mail address as contact and,
+// if an office is set, all modules of that office.
+func ownedBy(db *gorm.DB, User User) *gorm.DB {
+
+	if User.Office != "" {
+		return db.Where("lower(\"mail_address\") = lower(?) OR \"administration_office\" = ?", User.Mail, User.Office)
+	}
+
+	return db.Where("lower(\"mail_address\") = lower(?)", User.Mail)
+}
+
+// OwnedModules loads all modules owned by supplied user.
+func OwnedModules(db *gorm.DB, User User) []Module {
+
+	var Modules []Module
+	ownedBy(db, User).Order("\"title\" asc").Order("\"version\" desc").Find(&Modules)
+
+	return Modules
+}
+
+// FindOwnedModule loads the module with supplied ID only if
+// it is owned by supplied user. Otherwise, an empty module
+// is returned, recognizable by its empty URL.
+func FindOwnedModule(db *gorm.DB, User User, ID int) Module {
+
+	var Module Module
+	ownedBy(db, User).First(&Module, "\"id\" = ?", ID)
+
+	return Module
+}
+
+// modulesByID loads the modules with supplied IDs, keyed
+// by ID. Records referencing a module use this instead of
+// preloading, as gorm would take Module.ModuleID for the
+// foreign key of such a reference.
+func modulesByID(db *gorm.DB, IDs []int) map[int]Module {
+
+	Modules := make(map[int]Module)
+	if len(IDs) == 0 {
+		return Modules
+	}
+
+	var Loaded []Module
+	db.Where("\"id\" IN (?)", IDs).Find(&Loaded)
+
+	for _, Module := range Loaded {
+		Modules[Module.ID] = Module
+	}
+
+	return Modules
+}
+
+// LoadCatalogue loads all modules including their courses,
+// working efforts, exam elements and the statuses of their
+// links, ordered by title.
+func LoadCatalogue(db *gorm.DB) []Module {
+
+	var Modules []Module
+	db.Preload("Courses").Preload("WorkingEfforts").Preload("ExamElements").
+		Order("\"title\" asc").Order("\"version\" desc").Find(&Modules)
+
+	Statuses := LoadAllLinkStatuses(db)
+	for i := range Modules {
+		Modules[i].LinkStatuses = Statuses
+	}
+
+	return Modules
+}
+
+// AnchorFields returns all free text fields of a module
+// feedback can be anchored to, mapped to the category
+// the field is displayed in.
+func AnchorFields() map[string]int {
+
+	Fields := make(map[string]int)
+
+	Fields["LearningOutcomes"] = CATEGORY_LEARNING_OUTCOMES
+	Fields["LearningOutcomesEnglish"] = CATEGORY_LEARNING_OUTCOMES
+	Fields["TeachingContents"] = CATEGORY_TEACHING_CONTENTS
+	Fields["TeachingContentsEnglish"] = CATEGORY_TEACHING_CONTENTS
+	Fields["InstructiveForm"] = CATEGORY_INSTRUCTIVE_FORM
+	Fields["OptionalRequirements"] = CATEGORY_REQUIREMENTS
+	Fields["MandatoryRequirements"] = CATEGORY_REQUIREMENTS
+	Fields["ExaminationDescription"] = CATEGORY_EXAMINATION
+	Fields["RegistrationFormalities"] = CATEGORY_REGISTRATION_FORMALITIES
+	Fields["Literature"] = CATEGORY_LITERATURE
+	Fields["Miscellaneous"] = CATEGORY_MISCELLANEOUS
+
+	return Fields
+}
+
+// FieldTitles returns the titles of all free text
+// fields feedback can refer to as displayed to users.
+func FieldTitles() map[string]string {
+
+	Titles := make(map[string]string)
+
+	Titles["LearningOutcomes"] = "Lernergebnisse (Deutsch)"
+	Titles["LearningOutcomesEnglish"] = "Lernergebnisse (Englisch)"
+	Titles["TeachingContents"] = "Lehrinhalte (Deutsch)"
+	Titles["TeachingContentsEnglish"] = "Lehrinhalte (Englisch)"
+	Titles["InstructiveForm"] = "Beschreibung der Lehr- und Lernformen"
+	Titles["OptionalRequirements"] = "Wünschenswerte Voraussetzungen"
+	Titles["MandatoryRequirements"] = "Verpflichtende Voraussetzungen"
+	Titles["ExaminationDescription"] = "Prüfungsformbeschreibung"
+	Titles["RegistrationFormalities"] = "Anmeldeformalitäten"
+	Titles["Literature"] = "Literaturhinweise"
+	Titles["Miscellaneous"] = "Sonstiges"
+
+	return Titles
+}
+
+// NormalizeLineBreaks converts all line breaks to "\n",
+// just as browsers do when parsing the text.
+func NormalizeLineBreaks(Text string) string {
+	return strings.Replace(strings.Replace(Text, "\r\n", "\n", -1), "\r", "\n", -1)
+}
+
+// AnchorText returns the text of supplied free text field
+// as displayed to reviewers. Offsets of anchored feedback
+// refer to this text. Unknown fields are reported.
+func (module Module) AnchorText(Field string) (string, bool) {
+
+	var Text string
+
+	switch Field {
+	case "LearningOutcomes":
+		Text = module.LearningOutcomes.String
+	case "LearningOutcomesEnglish":
+		Text = module.LearningOutcomesEnglish.String
+	case "TeachingContents":
+		Text = module.TeachingContents.String
+	case "TeachingContentsEnglish":
+		Text = module.TeachingContentsEnglish.String
+	case "InstructiveForm":
+		Text = module.InstructiveForm
+	case "OptionalRequirements":
+		Text = module.OptionalRequirements
+	case "MandatoryRequirements":
+		Text = module.MandatoryRequirements.String
+	case "ExaminationDescription":
+		Text = module.ExaminationDescription.String
+	case "RegistrationFormalities":
+		Text = module.RegistrationFormalities.String
+	case "Literature":
+		Text = module.Literature
+	case "Miscellaneous":
+		Text = module.Miscellaneous.String
+	default:
+		return "", false
+	}
+
+	return NormalizeLineBreaks(Text), true
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Constants
+
+const (
+	// Reasons a user is notified for.
+	NOTIFICATION_MENTION = "mention"
+)
+
+// Structs
+
+// Notification informs a user about feedback of another
+// user, the actor, that concerns the user. Notifications
+// stay unread until the user opens them and are mailed at
+// most once as part of a digest. Module is loaded
+// separately, see modulesByID.
+type Notification struct {
+	ID         int       `gorm:"primary_key"`
+	UserID     string    `gorm:"index;not null"`
+	ActorID    string    `gorm:"not null"`
+	Actor      User      `gorm:"ForeignKey:ActorID"`
+	ModuleID   int       `gorm:"not null"`
+	Module     Module    `gorm:"-"`
+	FeedbackID int       `gorm:"index;not null"`
+	Kind       string    `gorm:"not null"`
+	Read       bool      `gorm:"index;not null;default:false"`
+	Mailed     bool      `gorm:"not null;default:false"`
+	Created    time.Time `gorm:"not null"`
+}
+
+// Functions
+
+// CountUnreadNotifications returns the amount of
+// notifications the user with supplied ID did not open.
+func CountUnreadNotifications(db *gorm.DB, UserID string) int {
+
+	Count := 0
+	db.Model(&Notification{}).Where("\"user_id\" = ? AND \"read\" = ?", UserID, false).Count(&Count)
+
+	return Count
+}
+
+// ListNotifications loads the latest notifications of
+// the user with supplied ID, including actor and module.
+func ListNotifications(db *gorm.DB, UserID string, Limit int) []Notification {
+
+	var Notifications []Notification
+	db.Preload("Actor").Where("\"user_id\" = ?", UserID).
+		Order("\"created\" desc").Limit(Limit).Find(&Notifications)
+
+	return loadNotifiedModules(db, Notifications)
+}
+
+// ListUnmailedNotifications loads all unread notifications of
+// the user with supplied ID that were not part of a digest yet.
+func ListUnmailedNotifications(db *gorm.DB, UserID string) []Notification {
+
+	var Notifications []Notification
+	db.Preload("Actor").Where("\"user_id\" = ? AND \"read\" = ? AND \"mailed\" = ?", UserID, false, false).
+		Order("\"created\" asc").Find(&Notifications)
+
+	return loadNotifiedModules(db, Notifications)
+}
+
+// loadNotifiedModules fills in the module of
+// each of supplied notifications.
+func loadNotifiedModules(db *gorm.DB, Notifications []Notification) []Notification {
+
+	IDs := make([]int, len(Notifications))
+	for i, Notification := range Notifications {
+		IDs[i] = Notification.ModuleID
+	}
+
+	Modules := modulesByID(db, IDs)
+	for i := range Notifications {
+		Notifications[i].Module = Modules[Notifications[i].ModuleID]
+	}
+
+	return Notifications
+}
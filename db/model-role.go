@@ -0,0 +1,146 @@
+package db
+
+import (
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Constants
+
+const (
+	// Permissions a handler can require from a user.
+	// CAUTION: Changes here will need to be reflected
+	// to other places, e.g. templates checking for
+	// permissions via (User).Can.
+	PERMISSION_READ              = "read"
+	PERMISSION_REVIEW            = "review"
+	PERMISSION_MODERATE_FEEDBACK = "moderate-feedback"
+	PERMISSION_SEND_FEEDBACK     = "send-feedback"
+	PERMISSION_MANAGE_USERS      = "manage-users"
+	PERMISSION_OWN_MODULES       = "own-modules"
+
+	// Names of the roles grouping above permissions.
+	ROLE_ADMIN       = "admin"
+	ROLE_REVIEWER    = "reviewer"
+	ROLE_MODERATOR   = "moderator"
+	ROLE_COORDINATOR = "coordinator"
+	ROLE_OBSERVER    = "observer"
+	ROLE_OWNER       = "owner"
+)
+
+// Structs
+
+// Role is a named set of permissions that
+// can be assigned to users. Permissions are
+// stored as comma-separated list.
+type Role struct {
+	ID          int    `gorm:"primary_key"`
+	Name        string `gorm:"not null;unique"`
+	Title       string `gorm:"not null"`
+	Permissions string `gorm:"not null"`
+}
+
+// Functions
+
+// DefaultRoles returns the roles MODULIST ships with.
+func DefaultRoles() []Role {
+
+	return []Role{
+		{
+			Name:  ROLE_ADMIN,
+			Title: "Admin",
+			Permissions: strings.Join([]string{PERMISSION_READ, PERMISSION_REVIEW, PERMISSION_MODERATE_FEEDBACK,
+				PERMISSION_SEND_FEEDBACK, PERMISSION_MANAGE_USERS}, ","),
+		},
+		{
+			Name:        ROLE_REVIEWER,
+			Title:       "Reviewer",
+			Permissions: strings.Join([]string{PERMISSION_READ, PERMISSION_REVIEW}, ","),
+		},
+		{
+			Name:        ROLE_MODERATOR,
+			Title:       "Moderation",
+			Permissions: strings.Join([]string{PERMISSION_READ, PERMISSION_REVIEW, PERMISSION_MODERATE_FEEDBACK}, ","),
+		},
+		{
+			Name:        ROLE_COORDINATOR,
+			Title:       "Feedbackversand",
+			Permissions: strings.Join([]string{PERMISSION_READ, PERMISSION_SEND_FEEDBACK}, ","),
+		},
+		{
+			Name:        ROLE_OBSERVER,
+			Title:       "Beobachter*in",
+			Permissions: PERMISSION_READ,
+		},
+		{
+			Name:        ROLE_OWNER,
+			Title:       "Fachgebiet",
+			Permissions: PERMISSION_OWN_MODULES,
+		},
+	}
+}
+
+// Grants reports whether this role contains
+// the supplied permission.
+func (role Role) Grants(Permission string) bool {
+
+	for _, p := range strings.Split(role.Permissions, ",") {
+
+		if p == Permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ListRoles loads all available roles.
+func ListRoles(db *gorm.DB) []Role {
+
+	var Roles []Role
+	db.Order("\"id\" asc").Find(&Roles)
+
+	return Roles
+}
+
+// FindRoles loads all roles with supplied names.
+func FindRoles(db *gorm.DB, Names []string) []Role {
+
+	var Roles []Role
+
+	if len(Names) > 0 {
+		db.Where("\"name\" IN (?)", Names).Find(&Roles)
+	}
+
+	return Roles
+}
+
+// SeedRoles makes sure all default roles exist
+// in the database without touching existing ones.
+func SeedRoles(db *gorm.DB) {
+
+	for _, role := range DefaultRoles() {
+		db.Where(Role{Name: role.Name}).Attrs(role).FirstOrCreate(&role)
+	}
+}
+
+// MigrateUserRoles assigns roles equivalent to the former
+// integer privileges to all accounts created before roles
+// were introduced and marks them as migrated.
+func MigrateUserRoles(db *gorm.DB) {
+
+	var Users []User
+	db.Where("\"privileges\" <> ?", PRIVILEGE_MIGRATED).Find(&Users)
+
+	for _, user := range Users {
+
+		roleName := ROLE_REVIEWER
+		if user.Privileges == PRIVILEGE_ADMIN {
+			roleName = ROLE_ADMIN
+		}
+
+		db.Model(&user).Association("Roles").Append(FindRoles(db, []string{roleName}))
+		db.Model(&user).Update("privileges", PRIVILEGE_MIGRATED)
+	}
+}
@@ -1,18 +1,22 @@
 package db
 
+import (
+	"time"
+)
+
 // Constants
 
 const (
-	// Privileges have to be kept monotonic.
-	// That means, a user with a lower integer
-	// privilege value will also have all privileges
-	// numerically greater than that value.
-	// CAUTION: Changes here will need to be reflected
-	// to other places, e.g. template and handler
-	// functions of admin's users site.
-	PRIVILEGE_ADMIN = iota
-	PRIVILEGE_REVIEWER
+	// Privileges were kept monotonic before they got
+	// replaced by roles and permissions. They are only
+	// read to migrate existing accounts to equivalent
+	// roles, afterwards an account is marked as migrated.
+	PRIVILEGE_MIGRATED = -1
+	PRIVILEGE_ADMIN    = 0
+	PRIVILEGE_REVIEWER = 1
+)
 
+const (
 	// Status groups as increasing integer.
 	// CAUTION: Changes here will need to be reflected
 	// to other places, e.g. template and handler
@@ -34,5 +38,77 @@ type User struct {
 	PasswordHash string `gorm:"not null;unique"`
 	StatusGroup  int    `gorm:"not null"`
 	Privileges   int    `gorm:"not null"`
+	Roles        []Role `gorm:"many2many:user_roles;"`
+	Office       string `gorm:"not null;default:''"`
 	Enabled      bool   `gorm:"not null"`
+
+	// Mail unread notifications as periodic digest.
+	NotificationDigest bool `gorm:"not null;default:false"`
+
+	// Mail a digest of events on watched modules this often,
+	// WatchDigestSent tells when the last one was sent.
+	WatchDigest     string `gorm:"not null;default:'off'"`
+	WatchDigestSent *time.Time
+}
+
+// Functions
+
+// Can reports whether any of the user's
+// roles grants the supplied permission.
+// Roles have to be preloaded for this.
+func (user User) Can(Permission string) bool {
+
+	for _, role := range user.Roles {
+
+		if role.Grants(Permission) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasRole reports whether the user was
+// assigned the role with supplied name.
+func (user User) HasRole(Name string) bool {
+
+	for _, role := range user.Roles {
+
+		if role.Name == Name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OnlyOwnsModules reports whether the user's roles
+// grant nothing but access to the user's own modules.
+// Roles have to be preloaded for this.
+func (user User) OnlyOwnsModules() bool {
+
+	if len(user.Roles) == 0 {
+		return false
+	}
+
+	for _, role := range user.Roles {
+
+		if role.Permissions != PERMISSION_OWN_MODULES {
+			return false
+		}
+	}
+
+	return true
+}
+
+// StatusGroupTitles returns a map of the short
+// titles of all status groups as displayed to users.
+func StatusGroupTitles() map[int]string {
+
+	return map[int]string{
+		STATUS_GROUP_PROF:  "Prof",
+		STATUS_GROUP_WIMI:  "WiMi",
+		STATUS_GROUP_STUDI: "Studi",
+		STATUS_GROUP_OTHER: "Sonstige",
+	}
 }
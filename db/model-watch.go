@@ -0,0 +1,107 @@
+package db
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// Constants
+
+const (
+	// How often users receive a digest of what
+	// happened on the modules they watch.
+	WATCH_DIGEST_OFF    = "off"
+	WATCH_DIGEST_DAILY  = "daily"
+	WATCH_DIGEST_WEEKLY = "weekly"
+)
+
+// Structs
+
+// Watch records that a user follows what happens on a
+// module. Users watch modules explicitly, by commenting
+// on them or, as module owners, by owning them. Stopping
+// to watch a module keeps the record with Active unset,
+// so that commenting again does not override the choice.
+type Watch struct {
+	UserID   string `gorm:"primary_key"`
+	ModuleID int    `gorm:"primary_key;auto_increment:false"`
+	Active   bool   `gorm:"not null"`
+}
+
+// Functions
+
+// WatchDigestTitles returns a map of the titles of
+// all digest frequencies as displayed to users.
+func WatchDigestTitles() map[string]string {
+
+	Titles := make(map[string]string)
+
+	Titles[WATCH_DIGEST_OFF] = "Nie"
+	Titles[WATCH_DIGEST_DAILY] = "Täglich"
+	Titles[WATCH_DIGEST_WEEKLY] = "Wöchentlich"
+
+	return Titles
+}
+
+// SetWatch starts or stops watching a module
+// explicitly for the user with supplied ID.
+func SetWatch(db *gorm.DB, UserID string, ModuleID int, Active bool) {
+
+	Watch := Watch{UserID: UserID, ModuleID: ModuleID}
+	db.Where(Watch).Assign(map[string]interface{}{"active": Active}).FirstOrCreate(&Watch)
+}
+
+// AutoWatch starts watching a module for the user with
+// supplied ID, unless the user decided on it before.
+func AutoWatch(db *gorm.DB, UserID string, ModuleID int) {
+
+	Watch := Watch{UserID: UserID, ModuleID: ModuleID}
+	db.Where(Watch).Attrs(map[string]interface{}{"active": true}).FirstOrCreate(&Watch)
+}
+
+// WatchedModuleIDs returns the IDs of all modules supplied
+// user watches, including owned ones not explicitly unwatched.
+// Roles of the user have to be preloaded.
+func WatchedModuleIDs(db *gorm.DB, User User) []int {
+
+	var Watches []Watch
+	db.Where("\"user_id\" = ?", User.ID).Find(&Watches)
+
+	Watched := make(map[int]bool)
+	for _, Watch := range Watches {
+		Watched[Watch.ModuleID] = Watch.Active
+	}
+
+	if User.Can(PERMISSION_OWN_MODULES) {
+
+		for _, Module := range OwnedModules(db, User) {
+
+			if _, decided := Watched[Module.ID]; !decided {
+				Watched[Module.ID] = true
+			}
+		}
+	}
+
+	IDs := []int{}
+	for ID, Active := range Watched {
+
+		if Active {
+			IDs = append(IDs, ID)
+		}
+	}
+
+	return IDs
+}
+
+// IsWatching reports whether supplied user watches the
+// module with supplied ID. Roles have to be preloaded.
+func IsWatching(db *gorm.DB, User User, ModuleID int) bool {
+
+	for _, ID := range WatchedModuleIDs(db, User) {
+
+		if ID == ModuleID {
+			return true
+		}
+	}
+
+	return false
+}
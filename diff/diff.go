@@ -0,0 +1,219 @@
+package diff
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Constants
+
+const (
+	// How a segment of the compared texts changed.
+	OP_EQUAL  = "equal"
+	OP_DELETE = "delete"
+	OP_INSERT = "insert"
+
+	// Largest amount of word pairs the comparison of
+	// the changed middle parts of two texts may look at.
+	// Memory only grows with the length of the texts,
+	// this bounds the time taken. Longer texts are
+	// reported as replaced entirely.
+	MAX_COMPARISONS = 4000000
+)
+
+// Structs
+
+// Segment is a run of text that was either kept,
+// deleted from the original or inserted into it.
+type Segment struct {
+	Op   string
+	Text string
+}
+
+// Functions
+
+// tokenize splits supplied text into words and the
+// whitespace between them, so that no character is lost.
+func tokenize(Text string) []string {
+
+	Tokens := []string{}
+	Current := []rune{}
+	inSpace := false
+
+	for _, r := range Text {
+
+		if (len(Current) > 0) && (unicode.IsSpace(r) != inSpace) {
+			Tokens = append(Tokens, string(Current))
+			Current = Current[:0]
+		}
+
+		inSpace = unicode.IsSpace(r)
+		Current = append(Current, r)
+	}
+
+	if len(Current) > 0 {
+		Tokens = append(Tokens, string(Current))
+	}
+
+	return Tokens
+}
+
+// appendSegment adds text to the list of segments,
+// merging it into the last segment of the same kind.
+func appendSegment(Segments []Segment, Op string, Text string) []Segment {
+
+	if Text == "" {
+		return Segments
+	}
+
+	if (len(Segments) > 0) && (Segments[len(Segments)-1].Op == Op) {
+		Segments[len(Segments)-1].Text += Text
+
+		return Segments
+	}
+
+	return append(Segments, Segment{Op: Op, Text: Text})
+}
+
+// Words compares two texts word by word and returns the
+// segments turning Original into Changed. Common prefix
+// and suffix are skipped before the longest common
+// subsequence of the remaining words is determined
+// with Hirschberg's algorithm in linear space.
+func Words(Original string, Changed string) []Segment {
+
+	a := tokenize(Original)
+	b := tokenize(Changed)
+
+	Prefix := 0
+	for (Prefix < len(a)) && (Prefix < len(b)) && (a[Prefix] == b[Prefix]) {
+		Prefix++
+	}
+
+	Suffix := 0
+	for (Suffix < (len(a) - Prefix)) && (Suffix < (len(b) - Prefix)) && (a[len(a)-1-Suffix] == b[len(b)-1-Suffix]) {
+		Suffix++
+	}
+
+	Segments := []Segment{}
+	Segments = appendSegment(Segments, OP_EQUAL, strings.Join(a[:Prefix], ""))
+
+	MiddleA := a[Prefix:(len(a) - Suffix)]
+	MiddleB := b[Prefix:(len(b) - Suffix)]
+
+	if (len(MiddleA) * len(MiddleB)) > MAX_COMPARISONS {
+		Segments = appendSegment(Segments, OP_DELETE, strings.Join(MiddleA, ""))
+		Segments = appendSegment(Segments, OP_INSERT, strings.Join(MiddleB, ""))
+	} else {
+		Segments = compare(Segments, MiddleA, MiddleB)
+	}
+
+	Segments = appendSegment(Segments, OP_EQUAL, strings.Join(a[(len(a)-Suffix):], ""))
+
+	return Segments
+}
+
+// lcsLengths returns the lengths of the longest common
+// subsequences of a and every prefix of b. Only two rows
+// of the usual table are kept.
+func lcsLengths(a []string, b []string) []int {
+
+	Previous := make([]int, (len(b) + 1))
+	Current := make([]int, (len(b) + 1))
+
+	for i := range a {
+
+		for j := range b {
+
+			if a[i] == b[j] {
+				Current[j+1] = Previous[j] + 1
+			} else if Previous[j+1] >= Current[j] {
+				Current[j+1] = Previous[j+1]
+			} else {
+				Current[j+1] = Current[j]
+			}
+		}
+
+		Previous, Current = Current, Previous
+	}
+
+	return Previous
+}
+
+// reversed returns a reversed copy of supplied words.
+func reversed(Words []string) []string {
+
+	Reversed := make([]string, len(Words))
+	for i, Word := range Words {
+		Reversed[len(Words)-1-i] = Word
+	}
+
+	return Reversed
+}
+
+// compare appends the segments turning a into b. It splits
+// a in half and finds the split of b at which the common
+// subsequences of both halves are longest together, then
+// compares both halves on their own.
+func compare(Segments []Segment, a []string, b []string) []Segment {
+
+	if (len(a) == 0) || (len(b) == 0) {
+		Segments = appendSegment(Segments, OP_DELETE, strings.Join(a, ""))
+
+		return appendSegment(Segments, OP_INSERT, strings.Join(b, ""))
+	}
+
+	if len(a) == 1 {
+
+		for j := range b {
+
+			if b[j] == a[0] {
+				Segments = appendSegment(Segments, OP_INSERT, strings.Join(b[:j], ""))
+				Segments = appendSegment(Segments, OP_EQUAL, a[0])
+
+				return appendSegment(Segments, OP_INSERT, strings.Join(b[(j+1):], ""))
+			}
+		}
+
+		Segments = appendSegment(Segments, OP_DELETE, a[0])
+
+		return appendSegment(Segments, OP_INSERT, strings.Join(b, ""))
+	}
+
+	Half := len(a) / 2
+	Front := lcsLengths(a[:Half], b)
+	Back := lcsLengths(reversed(a[Half:]), reversed(b))
+
+	Split := 0
+	for j := range Front {
+
+		if (Front[j] + Back[len(b)-j]) > (Front[Split] + Back[len(b)-Split]) {
+			Split = j
+		}
+	}
+
+	Segments = compare(Segments, a[:Half], b[:Split])
+
+	return compare(Segments, a[Half:], b[Split:])
+}
+
+// Plain renders segments as plain text, marking deleted
+// words with [-...-] and inserted words with {+...+}.
+func Plain(Segments []Segment) string {
+
+	var Text strings.Builder
+
+	for _, Segment := range Segments {
+
+		switch Segment.Op {
+		case OP_DELETE:
+			Text.WriteString("[-" + Segment.Text + "-]")
+		case OP_INSERT:
+			Text.WriteString("{+" + Segment.Text + "+}")
+		default:
+			Text.WriteString(Segment.Text)
+		}
+	}
+
+	return Text.String()
+}
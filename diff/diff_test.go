@@ -0,0 +1,97 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Functions
+
+// rebuild joins the text of all segments except those
+// of the skipped kind. Skipping deletions yields the
+// changed text, skipping insertions the original.
+func rebuild(Segments []Segment, Skip string) string {
+
+	var Text strings.Builder
+
+	for _, Segment := range Segments {
+
+		if Segment.Op != Skip {
+			Text.WriteString(Segment.Text)
+		}
+	}
+
+	return Text.String()
+}
+
+func TestWords(t *testing.T) {
+
+	Cases := []struct {
+		Name     string
+		Original string
+		Changed  string
+		Expected string
+	}{
+		{"insertion", "Die Klausur findet statt.", "Die schriftliche Klausur findet statt.", "Die {+schriftliche +}Klausur findet statt."},
+		{"deletion", "Die schriftliche Klausur findet statt.", "Die Klausur findet statt.", "Die [-schriftliche -]Klausur findet statt."},
+		{"replacement", "Vorlesung am Montag und Übung am Freitag", "Vorlesung am Dienstag und Übung am Freitag", "Vorlesung am [-Montag-]{+Dienstag+} und Übung am Freitag"},
+		{"identical", "gleich bleibt gleich", "gleich bleibt gleich", "gleich bleibt gleich"},
+		{"empty", "", "", ""},
+		{"empty original", "", "neu hier", "{+neu hier+}"},
+		{"empty change", "weg damit", "", "[-weg damit-]"},
+		{"non-ASCII", "Prüfung über Größen", "Prüfung über Maße", "Prüfung über [-Größen-]{+Maße+}"},
+		{"whitespace", "a  b\nc", "a b\n\nc", "a[-  -]{+ +}b[-\n-]{+\n\n+}c"},
+	}
+
+	for _, Case := range Cases {
+
+		Segments := Words(Case.Original, Case.Changed)
+
+		if Plain := Plain(Segments); Plain != Case.Expected {
+			t.Errorf("%s: expected %q, got %q", Case.Name, Case.Expected, Plain)
+		}
+
+		if Changed := rebuild(Segments, OP_DELETE); Changed != Case.Changed {
+			t.Errorf("%s: expected segments to rebuild changed text %q, got %q", Case.Name, Case.Changed, Changed)
+		}
+
+		if Original := rebuild(Segments, OP_INSERT); Original != Case.Original {
+			t.Errorf("%s: expected segments to rebuild original text %q, got %q", Case.Name, Case.Original, Original)
+		}
+	}
+}
+
+func TestWordsLongTexts(t *testing.T) {
+
+	// The longer texts exceed MAX_COMPARISONS and
+	// are reported as replaced entirely.
+	for _, Count := range []int{300, 3000} {
+
+		Original := []string{}
+		Changed := []string{}
+
+		for i := 0; i < Count; i++ {
+
+			Original = append(Original, fmt.Sprintf("wort%d", i%7))
+
+			if (i % 5) != 0 {
+				Changed = append(Changed, fmt.Sprintf("wort%d", i%11))
+			}
+		}
+
+		Segments := Words(strings.Join(Original, " "), strings.Join(Changed, " "))
+
+		if rebuild(Segments, OP_DELETE) != strings.Join(Changed, " ") {
+			t.Errorf("%d words: expected segments to rebuild changed text", Count)
+		}
+
+		if rebuild(Segments, OP_INSERT) != strings.Join(Original, " ") {
+			t.Errorf("%d words: expected segments to rebuild original text", Count)
+		}
+
+		if (Count == 300) && (len(Segments) < 10) {
+			t.Errorf("%d words: expected a word by word comparison, got %d segments", Count, len(Segments))
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/freitagsrunde/modulist/db"
+	"github.com/freitagsrunde/modulist/diff"
+	"github.com/freitagsrunde/modulist/markdown"
+)
+
+// Constants
+
+const (
+	// Subject of mails sending feedback to module owners.
+	FEEDBACK_MAIL_SUBJECT = "Feedback zu Ihren Modulbeschreibungen"
+)
+
+// Structs
+
+// FeedbackMail is the plain text mail containing all
+// feedback on the modules of one module owner.
+type FeedbackMail struct {
+	Mail    string
+	Modules int
+	Text    string
+}
+
+// Functions
+
+// indentLines prefixes all but the first line of
+// supplied text, so that multi-line comments stay
+// aligned below their list marker.
+func indentLines(Text string, Prefix string) string {
+	return strings.Replace(strings.TrimSpace(Text), "\n", ("\n" + Prefix), -1)
+}
+
+// ComposeFeedbackMails writes one plain text mail per module
+// owner address, listing the top-level comments and the course
+// comments of each module. Modules without a mail address are
+// skipped and counted.
+func ComposeFeedbackMails(Modules []db.Module, Threads []db.Feedback, CourseComments []db.CourseFeedback, Header string, Footer string, IncludeConsensus bool) ([]FeedbackMail, int) {
+
+	Titles := db.CategoryTitles()
+	SeverityTitles := db.SeverityTitles()
+	FieldTitles := db.FieldTitles()
+
+	// Group discussions by module, they are already
+	// sorted by category and support.
+	ThreadsByModule := make(map[int][]db.Feedback)
+	for _, Thread := range Threads {
+
+		if Thread.ParentID == 0 {
+			ThreadsByModule[Thread.ModuleID] = append(ThreadsByModule[Thread.ModuleID], Thread)
+		}
+	}
+
+	CommentsByCourse := make(map[int][]db.CourseFeedback)
+	for _, Comment := range CourseComments {
+		CommentsByCourse[Comment.CourseID] = append(CommentsByCourse[Comment.CourseID], Comment)
+	}
+
+	Bodies := make(map[string]*strings.Builder)
+	Counts := make(map[string]int)
+	withoutMail := 0
+
+	for _, Module := range Modules {
+
+		ModuleThreads := ThreadsByModule[Module.ID]
+
+		// Courses of the modules have to be loaded.
+		ModuleCourseComments := []db.CourseFeedback{}
+		for _, Course := range Module.Courses {
+			ModuleCourseComments = append(ModuleCourseComments, CommentsByCourse[Course.ID]...)
+		}
+
+		if (len(ModuleThreads) == 0) && (len(ModuleCourseComments) == 0) {
+			continue
+		}
+
+		Mail := strings.ToLower(strings.TrimSpace(Module.MailAddress.String))
+		if !Module.MailAddress.Valid || (Mail == "") {
+			withoutMail++
+
+			continue
+		}
+
+		if Bodies[Mail] == nil {
+			Bodies[Mail] = new(strings.Builder)
+		}
+		Body := Bodies[Mail]
+		Counts[Mail]++
+
+		Title := Module.Title.String
+		if !Module.Title.Valid {
+			Title = "Ohne Titel"
+		}
+
+		fmt.Fprintf(Body, "== %s (Modul #%d, Version %d) ==\n", Title, Module.ModuleID, Module.Version)
+
+		lastCategory := -1
+		for _, Thread := range ModuleThreads {
+
+			if Thread.Category != lastCategory {
+				fmt.Fprintf(Body, "\n-- %s --\n", Titles[Thread.Category])
+				lastCategory = Thread.Category
+			}
+
+			Comment := markdown.ToPlain(Thread.Comment)
+			if Thread.IsSuggestion() && (Comment == "") {
+				Comment = fmt.Sprintf("Änderungsvorschlag für %s", FieldTitles[Thread.SuggestionField])
+			}
+
+			fmt.Fprintf(Body, "* [%s] %s\n", SeverityTitles[Thread.Severity], indentLines(Comment, "  "))
+
+			// Suggestions list their changes and the complete text, ready to be copied.
+			if Thread.IsSuggestion() {
+				fmt.Fprintf(Body, "  Änderungen an %s ([-entfernt-], {+eingefügt+}):\n    %s\n", FieldTitles[Thread.SuggestionField], indentLines(diff.Plain(Thread.SuggestionDiff()), "    "))
+				fmt.Fprintf(Body, "  Vorgeschlagener Text:\n    %s\n", indentLines(Thread.Suggestion, "    "))
+			}
+
+			if Thread.AnchorField != "" {
+				fmt.Fprintf(Body, "  Textstelle: „%s“\n", indentLines(Thread.AnchorQuote, "  "))
+			}
+
+			if Thread.Tags != "" {
+				fmt.Fprintf(Body, "  Schlagworte: %s\n", strings.Join(Thread.TagList(), ", "))
+			}
+
+			if Thread.Votes > 0 {
+				fmt.Fprintf(Body, "  Zustimmungen weiterer Prüfender: %d\n", Thread.Votes)
+			}
+
+			// Replies are only included as consensus of a discussion.
+			if Consensus, ok := Thread.ConsensusReply(); IncludeConsensus && ok {
+				fmt.Fprintf(Body, "  Konsens: %s\n", indentLines(markdown.ToPlain(Consensus.Comment), "  "))
+			}
+		}
+
+		if len(ModuleCourseComments) > 0 {
+			Body.WriteString("\n-- Feedback zu den Lehrveranstaltungen --\n")
+		}
+
+		for _, Comment := range ModuleCourseComments {
+			fmt.Fprintf(Body, "* [%s] „%s“: %s\n", SeverityTitles[Comment.Severity], Comment.Course.Title, indentLines(markdown.ToPlain(Comment.Comment), "  "))
+		}
+
+		Body.WriteString("\n")
+	}
+
+	Mails := make([]FeedbackMail, 0, len(Bodies))
+
+	for Mail, Body := range Bodies {
+
+		Parts := []string{}
+		if strings.TrimSpace(Header) != "" {
+			Parts = append(Parts, strings.TrimSpace(Header))
+		}
+		Parts = append(Parts, strings.TrimSpace(Body.String()))
+		if strings.TrimSpace(Footer) != "" {
+			Parts = append(Parts, strings.TrimSpace(Footer))
+		}
+
+		Mails = append(Mails, FeedbackMail{
+			Mail:    Mail,
+			Modules: Counts[Mail],
+			Text:    (strings.Join(Parts, "\n\n") + "\n"),
+		})
+	}
+
+	// Present mails in a stable order.
+	sort.Slice(Mails, func(i, j int) bool {
+		return Mails[i].Mail < Mails[j].Mail
+	})
+
+	return Mails, withoutMail
+}
+
+// LoadFeedbackMails composes the feedback mails for all
+// modules with at least one comment selected by Filter,
+// on the module itself or on one of its courses. Comments
+// supported by most reviewers are listed first.
+func (app *App) LoadFeedbackMails(IncludeConsensus bool, Filter db.FeedbackFilter) ([]FeedbackMail, int) {
+
+	var ModuleIDs []int
+	app.DB.Model(&db.Feedback{}).Where("\"parent_id\" = ?", 0).Pluck("DISTINCT \"module_id\"", &ModuleIDs)
+
+	// Comments on courses are neither tagged nor voted on
+	// and count as open, as no one classifies them.
+	CourseComments := []db.CourseFeedback{}
+	for _, Comment := range db.ListCourseFeedback(app.DB, db.CommentedCourseIDs(app.DB)...) {
+
+		if Filter.Matches(db.Feedback{Severity: Comment.Severity, Status: db.STATUS_OPEN}) {
+			CourseComments = append(CourseComments, Comment)
+		}
+	}
+
+	CourseIDs := make([]int, len(CourseComments))
+	for i, Comment := range CourseComments {
+		CourseIDs[i] = Comment.CourseID
+	}
+
+	var CourseModuleIDs []int
+	if len(CourseIDs) > 0 {
+		app.DB.Table("module_courses").Where("\"course_id\" IN (?)", CourseIDs).Pluck("DISTINCT \"module_id\"", &CourseModuleIDs)
+	}
+
+	var Modules []db.Module
+	if (len(ModuleIDs) + len(CourseModuleIDs)) > 0 {
+		app.DB.Preload("Courses").Order("\"title\" asc").Order("\"version\" desc").
+			Find(&Modules, "\"id\" IN (?)", append(ModuleIDs, CourseModuleIDs...))
+	}
+
+	Threads := db.ListThreads(app.DB, ModuleIDs...)
+	db.LoadVotes(app.DB, Threads, "")
+	db.SortBySupport(Threads)
+
+	return ComposeFeedbackMails(Modules, db.FilterThreads(Threads, Filter), CourseComments,
+		db.LoadMailTemplate(app.DB, db.MAIL_TEMPLATE_HEADER), db.LoadMailTemplate(app.DB, db.MAIL_TEMPLATE_FOOTER), IncludeConsensus)
+}
@@ -0,0 +1,168 @@
+package graph
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Constants
+
+const (
+	// Titles shorter than this are too likely to
+	// appear in requirements without meaning the
+	// module, e.g. 'Mathematik'.
+	MIN_TITLE_LENGTH = 12
+)
+
+// Variables
+
+var (
+	// numberPattern matches five or six digit numbers
+	// as used to identify modules in the catalogue.
+	numberPattern = regexp.MustCompile(`\b[0-9]{5,6}\b`)
+
+	// cuePattern precedes numbers certainly meant to be
+	// module numbers, even if no such module is known.
+	cuePattern = regexp.MustCompile(`(?i)(modul(nummer|nr\.?)?|nr\.|#)\s*$`)
+)
+
+// Structs
+
+// Entry is a module of the catalogue references can
+// point to, usually the latest version of each module.
+type Entry struct {
+	ID     int
+	Number int
+	Title  string
+}
+
+// Reference is a module named in a text, either by number
+// or by title. ID is 0 if no module of the catalogue matched.
+type Reference struct {
+	ID   int
+	Text string
+}
+
+// Functions
+
+// isWordRune reports whether r belongs to a word.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// atBoundary reports whether the match at supplied byte
+// offsets is not part of a longer word.
+func atBoundary(Text string, Start int, End int) bool {
+
+	if Start > 0 {
+
+		Before := []rune(Text[:Start])
+		if isWordRune(Before[len(Before)-1]) {
+			return false
+		}
+	}
+
+	if End < len(Text) {
+
+		for _, r := range Text[End:] {
+			return !isWordRune(r)
+		}
+	}
+
+	return true
+}
+
+// Extract finds all modules of supplied catalogue named in
+// supplied text. Numbers are matched first, then titles,
+// longest first, so that 'Analysis II' is not taken for
+// 'Analysis I'. Numbers following a cue like 'Modul' or '#'
+// are returned with ID 0 if no module has that number.
+// Every module is returned once.
+func Extract(Text string, Catalogue []Entry) []Reference {
+
+	References := []Reference{}
+	Seen := make(map[string]bool)
+
+	add := func(Reference Reference) {
+
+		Key := strconv.Itoa(Reference.ID)
+		if Reference.ID == 0 {
+			Key = "#" + Reference.Text
+		}
+
+		if !Seen[Key] {
+			Seen[Key] = true
+			References = append(References, Reference)
+		}
+	}
+
+	ByNumber := make(map[int]Entry)
+	for _, Entry := range Catalogue {
+		ByNumber[Entry.Number] = Entry
+	}
+
+	for _, Match := range numberPattern.FindAllStringIndex(Text, -1) {
+
+		Number, _ := strconv.Atoi(Text[Match[0]:Match[1]])
+
+		if Entry, found := ByNumber[Number]; found {
+			add(Reference{ID: Entry.ID, Text: Text[Match[0]:Match[1]]})
+		} else if cuePattern.MatchString(Text[:Match[0]]) {
+			add(Reference{ID: 0, Text: Text[Match[0]:Match[1]]})
+		}
+	}
+
+	// Compare titles case-insensitively, longest first.
+	Titles := make([]Entry, 0, len(Catalogue))
+	for _, Entry := range Catalogue {
+
+		if len([]rune(strings.TrimSpace(Entry.Title))) >= MIN_TITLE_LENGTH {
+			Titles = append(Titles, Entry)
+		}
+	}
+
+	sort.SliceStable(Titles, func(i, j int) bool {
+		return len(Titles[i].Title) > len(Titles[j].Title)
+	})
+
+	Lower := strings.ToLower(Text)
+
+	// Offsets into the lowercase text only apply to
+	// the original text if lowercasing kept its length.
+	Original := Text
+	if len(Lower) != len(Text) {
+		Original = Lower
+	}
+
+	for _, Entry := range Titles {
+
+		Title := strings.ToLower(strings.TrimSpace(Entry.Title))
+
+		for Offset := 0; Offset < len(Lower); {
+
+			Start := strings.Index(Lower[Offset:], Title)
+			if Start < 0 {
+				break
+			}
+
+			Start += Offset
+			End := Start + len(Title)
+
+			if atBoundary(Lower, Start, End) {
+
+				add(Reference{ID: Entry.ID, Text: Original[Start:End]})
+
+				// Blank out the match, so that shorter
+				// titles contained in it do not match.
+				Lower = Lower[:Start] + strings.Repeat(" ", len(Title)) + Lower[End:]
+			}
+
+			Offset = End
+		}
+	}
+
+	return References
+}
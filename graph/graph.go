@@ -0,0 +1,223 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Structs
+
+// Node is a module within the graph.
+type Node struct {
+	ID    int
+	Label string
+}
+
+// Edge points from a required module to the module
+// requiring it. Optional requirements are recommended
+// but not mandatory.
+type Edge struct {
+	From      int
+	To        int
+	Mandatory bool
+}
+
+// Graph holds modules and the requirements between them.
+type Graph struct {
+	Nodes []Node
+	Edges []Edge
+}
+
+// Functions
+
+// Cycles returns all groups of modules requiring each
+// other, directly or transitively, using Tarjan's
+// algorithm for strongly connected components. Modules
+// requiring themselves form a group of their own.
+func (graph Graph) Cycles() [][]int {
+
+	Successors := make(map[int][]int)
+	SelfLoops := make(map[int]bool)
+	for _, Edge := range graph.Edges {
+
+		Successors[Edge.From] = append(Successors[Edge.From], Edge.To)
+		if Edge.From == Edge.To {
+			SelfLoops[Edge.From] = true
+		}
+	}
+
+	Index := make(map[int]int)
+	LowLink := make(map[int]int)
+	OnStack := make(map[int]bool)
+	Stack := []int{}
+	Counter := 0
+	Cycles := [][]int{}
+
+	var visit func(ID int)
+	visit = func(ID int) {
+
+		Index[ID] = Counter
+		LowLink[ID] = Counter
+		Counter++
+
+		Stack = append(Stack, ID)
+		OnStack[ID] = true
+
+		for _, Next := range Successors[ID] {
+
+			if _, visited := Index[Next]; !visited {
+
+				visit(Next)
+				if LowLink[Next] < LowLink[ID] {
+					LowLink[ID] = LowLink[Next]
+				}
+			} else if OnStack[Next] && (Index[Next] < LowLink[ID]) {
+				LowLink[ID] = Index[Next]
+			}
+		}
+
+		if LowLink[ID] != Index[ID] {
+			return
+		}
+
+		Component := []int{}
+		for {
+
+			Top := Stack[len(Stack)-1]
+			Stack = Stack[:(len(Stack) - 1)]
+			OnStack[Top] = false
+			Component = append(Component, Top)
+
+			if Top == ID {
+				break
+			}
+		}
+
+		if (len(Component) > 1) || SelfLoops[ID] {
+			sort.Ints(Component)
+			Cycles = append(Cycles, Component)
+		}
+	}
+
+	for _, Node := range graph.Nodes {
+
+		if _, visited := Index[Node.ID]; !visited {
+			visit(Node.ID)
+		}
+	}
+
+	return Cycles
+}
+
+// Neighbourhood returns the part of the graph relevant to
+// the module with supplied ID: all modules it requires,
+// directly or transitively, and the modules directly
+// requiring it.
+func (graph Graph) Neighbourhood(ID int) Graph {
+
+	Predecessors := make(map[int][]Edge)
+	for _, Edge := range graph.Edges {
+		Predecessors[Edge.To] = append(Predecessors[Edge.To], Edge)
+	}
+
+	Included := map[int]bool{ID: true}
+	Edges := []Edge{}
+	Seen := make(map[Edge]bool)
+
+	add := func(Edge Edge) {
+		if !Seen[Edge] {
+			Seen[Edge] = true
+			Edges = append(Edges, Edge)
+		}
+	}
+
+	// Walk requirements backwards from the module.
+	Queue := []int{ID}
+	for len(Queue) > 0 {
+
+		Current := Queue[0]
+		Queue = Queue[1:]
+
+		for _, Edge := range Predecessors[Current] {
+
+			add(Edge)
+			if !Included[Edge.From] {
+				Included[Edge.From] = true
+				Queue = append(Queue, Edge.From)
+			}
+		}
+	}
+
+	for _, Edge := range graph.Edges {
+
+		if Edge.From == ID {
+			add(Edge)
+			Included[Edge.To] = true
+		}
+	}
+
+	Nodes := []Node{}
+	for _, Node := range graph.Nodes {
+
+		if Included[Node.ID] {
+			Nodes = append(Nodes, Node)
+		}
+	}
+
+	return Graph{Nodes: Nodes, Edges: Edges}
+}
+
+// Connected returns the graph without modules
+// neither requiring nor required by any other.
+func (graph Graph) Connected() Graph {
+
+	Included := make(map[int]bool)
+	for _, Edge := range graph.Edges {
+		Included[Edge.From] = true
+		Included[Edge.To] = true
+	}
+
+	Nodes := []Node{}
+	for _, Node := range graph.Nodes {
+
+		if Included[Node.ID] {
+			Nodes = append(Nodes, Node)
+		}
+	}
+
+	return Graph{Nodes: Nodes, Edges: graph.Edges}
+}
+
+// dotString quotes supplied text for use in DOT.
+func dotString(Text string) string {
+	return "\"" + strings.Replace(strings.Replace(Text, "\\", "\\\\", -1), "\"", "\\\"", -1) + "\""
+}
+
+// DOT renders the graph in the DOT language of Graphviz.
+// Optional requirements are drawn dashed.
+func (graph Graph) DOT() string {
+
+	var DOT strings.Builder
+
+	DOT.WriteString("digraph prerequisites {\n")
+	DOT.WriteString("\trankdir=TB;\n\tnode [shape=box, fontname=\"Helvetica\"];\n")
+
+	for _, Node := range graph.Nodes {
+		fmt.Fprintf(&DOT, "\tm%d [label=%s];\n", Node.ID, dotString(Node.Label))
+	}
+
+	for _, Edge := range graph.Edges {
+
+		Style := ""
+		if !Edge.Mandatory {
+			Style = " [style=dashed]"
+		}
+
+		fmt.Fprintf(&DOT, "\tm%d -> m%d%s;\n", Edge.From, Edge.To, Style)
+	}
+
+	DOT.WriteString("}\n")
+
+	return DOT.String()
+}
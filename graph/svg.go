@@ -0,0 +1,208 @@
+package graph
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+)
+
+// Constants
+
+const (
+	// Dimensions of the drawing in pixels.
+	svgNodeWidth  = 220
+	svgNodeHeight = 40
+	svgGapX       = 30
+	svgGapY       = 60
+	svgMargin     = 20
+
+	// Characters of a label fitting into a node.
+	svgLabelLength = 32
+)
+
+// Functions
+
+// backEdges returns the edges closing a cycle when walking
+// the graph depth-first. Without them, the graph is acyclic.
+func (graph Graph) backEdges() map[Edge]bool {
+
+	Successors := make(map[int][]Edge)
+	for _, Edge := range graph.Edges {
+		Successors[Edge.From] = append(Successors[Edge.From], Edge)
+	}
+
+	Back := make(map[Edge]bool)
+	Visited := make(map[int]bool)
+	OnPath := make(map[int]bool)
+
+	var visit func(ID int)
+	visit = func(ID int) {
+
+		Visited[ID] = true
+		OnPath[ID] = true
+
+		for _, Edge := range Successors[ID] {
+
+			if OnPath[Edge.To] {
+				Back[Edge] = true
+			} else if !Visited[Edge.To] {
+				visit(Edge.To)
+			}
+		}
+
+		OnPath[ID] = false
+	}
+
+	for _, Node := range graph.Nodes {
+
+		if !Visited[Node.ID] {
+			visit(Node.ID)
+		}
+	}
+
+	return Back
+}
+
+// layers assigns each module the length of the longest
+// chain of requirements leading to it, so that required
+// modules are drawn above the ones requiring them. Edges
+// closing a cycle are left out.
+func (graph Graph) layers() map[int]int {
+
+	Back := graph.backEdges()
+
+	Layers := make(map[int]int)
+	for _, Node := range graph.Nodes {
+		Layers[Node.ID] = 0
+	}
+
+	for Round := 0; Round < len(graph.Nodes); Round++ {
+
+		Changed := false
+		for _, Edge := range graph.Edges {
+
+			if !Back[Edge] && (Layers[Edge.To] < (Layers[Edge.From] + 1)) {
+				Layers[Edge.To] = Layers[Edge.From] + 1
+				Changed = true
+			}
+		}
+
+		if !Changed {
+			break
+		}
+	}
+
+	return Layers
+}
+
+// shorten cuts supplied label to fit into a node.
+func shorten(Label string) string {
+
+	Runes := []rune(Label)
+	if len(Runes) > svgLabelLength {
+		return string(Runes[:(svgLabelLength-1)]) + "…"
+	}
+
+	return Label
+}
+
+// SVG draws the graph as SVG image, required modules above
+// the modules requiring them. The module with supplied ID
+// is highlighted, 0 highlights none. Modules link to their
+// page via supplied format taking the ID, e.g.
+// '/review/module/%d'. Optional requirements are dashed.
+func (graph Graph) SVG(HighlightID int, LinkFormat string) string {
+
+	Layers := graph.layers()
+
+	// Group modules by layer, ordered by label within.
+	Rows := [][]Node{}
+	for _, Current := range graph.Nodes {
+
+		for len(Rows) <= Layers[Current.ID] {
+			Rows = append(Rows, []Node{})
+		}
+
+		Rows[Layers[Current.ID]] = append(Rows[Layers[Current.ID]], Current)
+	}
+
+	Width := 0
+	for _, Row := range Rows {
+
+		sort.SliceStable(Row, func(i, j int) bool {
+			return Row[i].Label < Row[j].Label
+		})
+
+		if RowWidth := len(Row)*(svgNodeWidth+svgGapX) - svgGapX; RowWidth > Width {
+			Width = RowWidth
+		}
+	}
+
+	// Center each row and remember where each module is.
+	type position struct{ X, Y int }
+	Positions := make(map[int]position)
+
+	for i, Row := range Rows {
+
+		RowWidth := len(Row)*(svgNodeWidth+svgGapX) - svgGapX
+		for j, Node := range Row {
+			Positions[Node.ID] = position{
+				X: svgMargin + (Width-RowWidth)/2 + j*(svgNodeWidth+svgGapX),
+				Y: svgMargin + i*(svgNodeHeight+svgGapY),
+			}
+		}
+	}
+
+	Height := len(Rows)*(svgNodeHeight+svgGapY) - svgGapY
+	if Height < 0 {
+		Height = 0
+	}
+
+	var SVG strings.Builder
+
+	fmt.Fprintf(&SVG, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" font-family=\"Helvetica, Arial, sans-serif\" font-size=\"12\">\n",
+		Width+2*svgMargin, Height+2*svgMargin)
+	SVG.WriteString("<defs><marker id=\"arrow\" viewBox=\"0 0 10 10\" refX=\"10\" refY=\"5\" markerWidth=\"8\" markerHeight=\"8\" orient=\"auto\"><path d=\"M 0 0 L 10 5 L 0 10 z\" fill=\"#555\"/></marker></defs>\n")
+
+	for _, Edge := range graph.Edges {
+
+		From, foundFrom := Positions[Edge.From]
+		To, foundTo := Positions[Edge.To]
+		if !foundFrom || !foundTo || (Edge.From == Edge.To) {
+			continue
+		}
+
+		Dash := ""
+		if !Edge.Mandatory {
+			Dash = " stroke-dasharray=\"5,4\""
+		}
+
+		// Edges against the layering are part of a cycle.
+		Color := "#555"
+		if To.Y <= From.Y {
+			Color = "#a94442"
+		}
+
+		fmt.Fprintf(&SVG, "<line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\" stroke=\"%s\"%s marker-end=\"url(#arrow)\"/>\n",
+			From.X+svgNodeWidth/2, From.Y+svgNodeHeight, To.X+svgNodeWidth/2, To.Y, Color, Dash)
+	}
+
+	for _, Node := range graph.Nodes {
+
+		Position := Positions[Node.ID]
+
+		Fill := "#f5f5f5"
+		if Node.ID == HighlightID {
+			Fill = "#d9edf7"
+		}
+
+		fmt.Fprintf(&SVG, "<a href=\"%s\" target=\"_top\"><title>%s</title>", html.EscapeString(fmt.Sprintf(LinkFormat, Node.ID)), html.EscapeString(Node.Label))
+		fmt.Fprintf(&SVG, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" rx=\"4\" fill=\"%s\" stroke=\"#999\"/>", Position.X, Position.Y, svgNodeWidth, svgNodeHeight, Fill)
+		fmt.Fprintf(&SVG, "<text x=\"%d\" y=\"%d\" text-anchor=\"middle\">%s</text></a>\n", Position.X+svgNodeWidth/2, Position.Y+svgNodeHeight/2+4, html.EscapeString(shorten(Node.Label)))
+	}
+
+	SVG.WriteString("</svg>\n")
+
+	return SVG.String()
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/freitagsrunde/modulist/db"
+)
+
+// Constants
+
+const (
+	// What happened to feedback on a module.
+	FEEDBACK_CREATED = "created"
+	FEEDBACK_EDITED  = "edited"
+	FEEDBACK_DELETED = "deleted"
+
+	// Events buffered per subscriber. Further events
+	// are dropped for subscribers not keeping up.
+	HUB_BUFFER_SIZE = 32
+)
+
+// Structs
+
+// FeedbackEvent tells subscribers of a module which
+// feedback changed, without including its content.
+// Subscribers load the feedback themselves, as what
+// they may see depends on their permissions.
+type FeedbackEvent struct {
+	Type       string
+	ModuleID   int
+	Category   int
+	FeedbackID int
+	ParentID   int
+	ActorID    string
+}
+
+// Hub passes events on feedback of a module to all
+// subscribers of that module within this process.
+type Hub struct {
+	lock        sync.Mutex
+	subscribers map[int]map[chan FeedbackEvent]bool
+}
+
+// Functions
+
+// NewHub returns a hub without subscribers.
+func NewHub() *Hub {
+
+	return &Hub{
+		subscribers: make(map[int]map[chan FeedbackEvent]bool),
+	}
+}
+
+// Subscribe returns a channel receiving all events
+// on feedback of the module with supplied ID.
+func (hub *Hub) Subscribe(ModuleID int) chan FeedbackEvent {
+
+	hub.lock.Lock()
+	defer hub.lock.Unlock()
+
+	Events := make(chan FeedbackEvent, HUB_BUFFER_SIZE)
+
+	if hub.subscribers[ModuleID] == nil {
+		hub.subscribers[ModuleID] = make(map[chan FeedbackEvent]bool)
+	}
+	hub.subscribers[ModuleID][Events] = true
+
+	return Events
+}
+
+// Unsubscribe stops passing events to supplied
+// channel and closes it.
+func (hub *Hub) Unsubscribe(ModuleID int, Events chan FeedbackEvent) {
+
+	hub.lock.Lock()
+	defer hub.lock.Unlock()
+
+	if _, found := hub.subscribers[ModuleID][Events]; !found {
+		return
+	}
+
+	delete(hub.subscribers[ModuleID], Events)
+	if len(hub.subscribers[ModuleID]) == 0 {
+		delete(hub.subscribers, ModuleID)
+	}
+
+	close(Events)
+}
+
+// Publish passes supplied event to all subscribers of
+// its module without blocking on slow subscribers.
+func (hub *Hub) Publish(Event FeedbackEvent) {
+
+	hub.lock.Lock()
+	defer hub.lock.Unlock()
+
+	for Events := range hub.subscribers[Event.ModuleID] {
+
+		select {
+		case Events <- Event:
+		default:
+		}
+	}
+}
+
+// publishFeedback lets subscribers of its module know
+// that supplied user changed supplied feedback.
+func (app *App) publishFeedback(Type string, Actor db.User, Feedback db.Feedback) {
+
+	app.Hub.Publish(FeedbackEvent{
+		Type:       Type,
+		ModuleID:   Feedback.ModuleID,
+		Category:   Feedback.Category,
+		FeedbackID: Feedback.ID,
+		ParentID:   Feedback.ParentID,
+		ActorID:    Actor.ID,
+	})
+}
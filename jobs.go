@@ -0,0 +1,50 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// Functions
+
+// StartJobs launches all periodic background jobs.
+// Digests of notifications and the link checker have
+// to be enabled in the .env file, digests of watched
+// modules are sent as configured by each user.
+func (app *App) StartJobs() {
+
+	go runEvery("SendWatchDigests", WATCH_DIGEST_CHECK_INTERVAL, app.SendWatchDigests)
+
+	if app.DigestInterval > 0 {
+		go runEvery("SendNotificationDigests", app.DigestInterval, app.SendNotificationDigests)
+	}
+
+	if app.LinkCheckInterval > 0 {
+		go runEvery("CheckLinks", app.LinkCheckInterval, app.CheckLinks)
+	}
+}
+
+// runEvery runs supplied job once per interval for as long
+// as MODULIST is running. A failing run is logged and does
+// not stop later runs.
+func runEvery(Name string, Interval time.Duration, Job func()) {
+
+	ticker := time.NewTicker(Interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		runJob(Name, Job)
+	}
+}
+
+// runJob runs supplied job once and recovers from panics.
+func runJob(Name string, Job func()) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[%s] Job failed: %v.\n", Name, r)
+		}
+	}()
+
+	Job()
+}
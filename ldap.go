@@ -0,0 +1,248 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"crypto/tls"
+	"net/url"
+
+	"github.com/freitagsrunde/modulist/db"
+	"github.com/jinzhu/gorm"
+	"gopkg.in/ldap.v2"
+)
+
+// Structs
+
+// LDAPConn is the part of an LDAP connection the
+// LDAPAuthenticator needs. It is satisfied by *ldap.Conn
+// and allows to replace the directory by a stub.
+type LDAPConn interface {
+	Bind(username, password string) error
+	Search(searchRequest *ldap.SearchRequest) (*ldap.SearchResult, error)
+	Close()
+}
+
+// LDAPAuthenticator authenticates users by binding to
+// an LDAP directory with the supplied credentials. Group
+// filters decide which role a user receives.
+type LDAPAuthenticator struct {
+	DB                  *gorm.DB
+	Dial                func() (LDAPConn, error)
+	BindDNTemplate      string
+	GroupBaseDN         string
+	AdminGroupFilter    string
+	ReviewerGroupFilter string
+	AutoProvision       bool
+	Provision           func(FirstName string, LastName string, Mail string, RoleNames []string) (*db.User, error)
+}
+
+// Functions
+
+// InitLDAPAuthenticator reads the LDAP configuration from
+// the .env file and prepares an LDAPAuthenticator with it.
+func (app *App) InitLDAPAuthenticator() *LDAPAuthenticator {
+
+	ldapURL, err := url.Parse(os.Getenv("LDAP_URL"))
+	if (err != nil) || (ldapURL.Host == "") {
+		log.Fatal("[InitLDAPAuthenticator] Could not parse LDAP_URL from .env file. Terminating.")
+	}
+
+	startTLS, err := strconv.ParseBool(os.Getenv("LDAP_STARTTLS"))
+	if err != nil {
+		log.Fatal("[InitLDAPAuthenticator] Unrecognized LDAP_STARTTLS indicator in .env, expecting bool. Terminating.")
+	}
+
+	autoProvision, err := strconv.ParseBool(os.Getenv("LDAP_AUTO_PROVISION"))
+	if err != nil {
+		log.Fatal("[InitLDAPAuthenticator] Unrecognized LDAP_AUTO_PROVISION indicator in .env, expecting bool. Terminating.")
+	}
+
+	bindDNTemplate := os.Getenv("LDAP_BIND_DN_TEMPLATE")
+	if !strings.Contains(bindDNTemplate, "{uid}") && !strings.Contains(bindDNTemplate, "{mail}") {
+		log.Fatal("[InitLDAPAuthenticator] LDAP_BIND_DN_TEMPLATE has to contain '{uid}' or '{mail}'. Terminating.")
+	}
+
+	tlsConfig := &tls.Config{ServerName: ldapURL.Hostname()}
+
+	dial := func() (LDAPConn, error) {
+
+		// Connect either via LDAPS or plain LDAP with optional StartTLS.
+		if ldapURL.Scheme == "ldaps" {
+			return ldap.DialTLS("tcp", ldapURL.Host, tlsConfig)
+		}
+
+		conn, err := ldap.Dial("tcp", ldapURL.Host)
+		if err != nil {
+			return nil, err
+		}
+
+		if startTLS {
+
+			if err := conn.StartTLS(tlsConfig); err != nil {
+				conn.Close()
+
+				return nil, err
+			}
+		}
+
+		return conn, nil
+	}
+
+	return &LDAPAuthenticator{
+		DB:                  app.DB,
+		Dial:                dial,
+		BindDNTemplate:      bindDNTemplate,
+		GroupBaseDN:         os.Getenv("LDAP_GROUP_BASE_DN"),
+		AdminGroupFilter:    os.Getenv("LDAP_ADMIN_GROUP_FILTER"),
+		ReviewerGroupFilter: os.Getenv("LDAP_REVIEWER_GROUP_FILTER"),
+		AutoProvision:       autoProvision,
+		Provision:           app.ProvisionUser,
+	}
+}
+
+// EscapeDN escapes a value to be safely
+// used as part of an LDAP distinguished name.
+func EscapeDN(Value string) string {
+
+	var escaped strings.Builder
+
+	for i, char := range Value {
+
+		switch {
+		case strings.ContainsRune(",+\"\\<>;=", char):
+			escaped.WriteRune('\\')
+			escaped.WriteRune(char)
+		case (i == 0) && ((char == ' ') || (char == '#')):
+			escaped.WriteRune('\\')
+			escaped.WriteRune(char)
+		case (i == (len(Value) - 1)) && (char == ' '):
+			escaped.WriteRune('\\')
+			escaped.WriteRune(char)
+		case char == 0:
+			escaped.WriteString("\\00")
+		default:
+			escaped.WriteRune(char)
+		}
+	}
+
+	return escaped.String()
+}
+
+// Authenticate implements Authenticator for LDAP directories.
+func (dir *LDAPAuthenticator) Authenticate(Mail string, Password string) (*db.User, error) {
+
+	// An empty password would result in an anonymous bind
+	// which most directories accept. Never allow that.
+	if (Mail == "") || (Password == "") {
+		return nil, ErrInvalidCredentials
+	}
+
+	uid := Mail
+	if at := strings.Index(Mail, "@"); at > 0 {
+		uid = Mail[:at]
+	}
+
+	userDN := strings.NewReplacer("{uid}", EscapeDN(uid), "{mail}", EscapeDN(Mail)).Replace(dir.BindDNTemplate)
+
+	conn, err := dir.Dial()
+	if err != nil {
+		return nil, fmt.Errorf("connecting to LDAP directory failed: %s", err)
+	}
+	defer conn.Close()
+
+	// Bind as user with supplied password.
+	if err := conn.Bind(userDN, Password); err != nil {
+
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+
+		return nil, fmt.Errorf("binding to LDAP directory failed: %s", err)
+	}
+
+	// Read names of user from directory entry.
+	entries, err := conn.Search(ldap.NewSearchRequest(userDN, ldap.ScopeBaseObject, ldap.NeverDerefAliases,
+		1, 0, false, "(objectClass=*)", []string{"givenName", "sn", "cn"}, nil))
+	if (err != nil) || (len(entries.Entries) != 1) {
+		return nil, errors.New("reading user entry from LDAP directory failed")
+	}
+	entry := entries.Entries[0]
+
+	// Map group memberships to roles.
+	roleName := ""
+
+	if dir.memberOf(conn, dir.AdminGroupFilter, userDN, uid) {
+		roleName = db.ROLE_ADMIN
+	} else if dir.memberOf(conn, dir.ReviewerGroupFilter, userDN, uid) {
+		roleName = db.ROLE_REVIEWER
+	}
+
+	if roleName == "" {
+		log.Printf("[LDAPAuthenticator] User '%s' authenticated but is in none of the configured groups.\n", Mail)
+
+		return nil, ErrInvalidCredentials
+	}
+
+	// Find corresponding MODULIST account.
+	var User db.User
+	dir.DB.Preload("Roles").First(&User, "lower(\"mail\") = lower(?)", Mail)
+
+	if User.ID == "" {
+
+		if !dir.AutoProvision {
+			return nil, ErrInvalidCredentials
+		}
+
+		firstName := entry.GetAttributeValue("givenName")
+		lastName := entry.GetAttributeValue("sn")
+		if lastName == "" {
+			lastName = entry.GetAttributeValue("cn")
+		}
+
+		return dir.Provision(firstName, lastName, strings.ToLower(Mail), []string{roleName})
+	}
+
+	if !User.Enabled {
+		return nil, ErrInvalidCredentials
+	}
+
+	// Grant the role derived from the directory. Further
+	// roles assigned inside MODULIST are left untouched.
+	if !User.HasRole(roleName) {
+		dir.DB.Model(&User).Association("Roles").Append(db.FindRoles(dir.DB, []string{roleName}))
+	}
+
+	return &User, nil
+}
+
+// memberOf reports whether the supplied group filter matches
+// at least one entry below the configured group base DN.
+func (dir *LDAPAuthenticator) memberOf(conn LDAPConn, filter string, userDN string, uid string) bool {
+
+	if filter == "" {
+		return false
+	}
+
+	filter = strings.NewReplacer("{dn}", ldap.EscapeFilter(userDN), "{uid}", ldap.EscapeFilter(uid)).Replace(filter)
+
+	result, err := conn.Search(ldap.NewSearchRequest(dir.GroupBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		1, 0, false, filter, []string{"dn"}, nil))
+	if err != nil {
+
+		// Hitting the size limit still means we found a match.
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+			return true
+		}
+
+		log.Printf("[LDAPAuthenticator] Group search with filter '%s' failed: %s.\n", filter, err)
+
+		return false
+	}
+
+	return len(result.Entries) > 0
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/freitagsrunde/modulist/db"
+	"gopkg.in/ldap.v2"
+)
+
+// Constants
+
+const (
+	TEST_GROUP_BASE_DN = "ou=groups,dc=example,dc=org"
+)
+
+// Structs
+
+// testDirectory is an in-process stand-in for an LDAP
+// directory, holding people with password and names
+// as well as the members of each group.
+type testDirectory struct {
+	Passwords map[string]string
+	Entries   map[string]map[string][]string
+	Groups    map[string][]string
+	Dialed    int
+}
+
+// testDirectoryConn is a connection to a testDirectory.
+// Like in a real directory, DNs are matched ignoring case.
+type testDirectoryConn struct {
+	dir *testDirectory
+}
+
+// Functions
+
+func newTestDirectory() *testDirectory {
+
+	return &testDirectory{
+		Passwords: map[string]string{
+			"uid=erika,ou=people,dc=example,dc=org": "richtig",
+			"uid=max,ou=people,dc=example,dc=org":   "geheim",
+			"uid=otto,ou=people,dc=example,dc=org":  "passwort",
+		},
+		Entries: map[string]map[string][]string{
+			"uid=erika,ou=people,dc=example,dc=org": {"givenName": {"Erika"}, "sn": {"Mustermann"}},
+			"uid=max,ou=people,dc=example,dc=org":   {"cn": {"Max Muster"}},
+			"uid=otto,ou=people,dc=example,dc=org":  {"givenName": {"Otto"}, "sn": {"Normal"}},
+		},
+		Groups: map[string][]string{
+			"admins":    {"uid=max,ou=people,dc=example,dc=org"},
+			"reviewers": {"uid=erika,ou=people,dc=example,dc=org"},
+		},
+	}
+}
+
+func (conn *testDirectoryConn) Bind(username, password string) error {
+
+	if Password, ok := conn.dir.Passwords[strings.ToLower(username)]; !ok || (Password != password) {
+		return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+	}
+
+	return nil
+}
+
+func (conn *testDirectoryConn) Search(searchRequest *ldap.SearchRequest) (*ldap.SearchResult, error) {
+
+	Result := &ldap.SearchResult{}
+
+	// Group searches match filters of the form used in newTestAuthenticator.
+	if searchRequest.BaseDN == TEST_GROUP_BASE_DN {
+
+		for Group, Members := range conn.dir.Groups {
+
+			for _, Member := range Members {
+
+				if strings.ToLower(searchRequest.Filter) == fmt.Sprintf("(&(cn=%s)(member=%s))", Group, ldap.EscapeFilter(Member)) {
+					Result.Entries = append(Result.Entries, ldap.NewEntry(fmt.Sprintf("cn=%s,%s", Group, TEST_GROUP_BASE_DN), nil))
+				}
+			}
+		}
+
+		return Result, nil
+	}
+
+	if Attributes, ok := conn.dir.Entries[strings.ToLower(searchRequest.BaseDN)]; ok {
+		Result.Entries = append(Result.Entries, ldap.NewEntry(searchRequest.BaseDN, Attributes))
+
+		return Result, nil
+	}
+
+	return nil, ldap.NewError(ldap.LDAPResultNoSuchObject, errors.New("no such object"))
+}
+
+func (conn *testDirectoryConn) Close() {}
+
+// newTestAuthenticator returns an authenticator talking to
+// supplied directory and saving accounts in the app's database.
+func newTestAuthenticator(app *App, dir *testDirectory, AutoProvision bool) *LDAPAuthenticator {
+
+	return &LDAPAuthenticator{
+		DB: app.DB,
+		Dial: func() (LDAPConn, error) {
+			dir.Dialed++
+
+			return &testDirectoryConn{dir: dir}, nil
+		},
+		BindDNTemplate:      "uid={uid},ou=people,dc=example,dc=org",
+		GroupBaseDN:         TEST_GROUP_BASE_DN,
+		AdminGroupFilter:    "(&(cn=admins)(member={dn}))",
+		ReviewerGroupFilter: "(&(cn=reviewers)(member={dn}))",
+		AutoProvision:       AutoProvision,
+		Provision:           app.ProvisionUser,
+	}
+}
+
+func TestLDAPLinksExistingUser(t *testing.T) {
+
+	app := newTestApp(t)
+	createTestUser(t, app, "erika@example.org", "unused", true, db.ROLE_OWNER)
+
+	User, err := newTestAuthenticator(app, newTestDirectory(), false).Authenticate("Erika@example.org", "richtig")
+	if err != nil {
+		t.Fatalf("expected successful login, got %s", err)
+	}
+
+	// Role from directory is added, the one assigned in MODULIST kept.
+	if !User.HasRole(db.ROLE_REVIEWER) || !User.HasRole(db.ROLE_OWNER) {
+		t.Errorf("expected returned user to hold reviewer and owner role, got %+v", User.Roles)
+	}
+
+	Saved := loadTestUser(app, "erika@example.org")
+	if (len(Saved.Roles) != 2) || !Saved.HasRole(db.ROLE_REVIEWER) || !Saved.HasRole(db.ROLE_OWNER) {
+		t.Errorf("expected saved user to hold reviewer and owner role, got %+v", Saved.Roles)
+	}
+
+	if LandingPage(*User) != "/modules" {
+		t.Errorf("expected reviewer to land on module list, got %s", LandingPage(*User))
+	}
+}
+
+func TestLDAPProvisionsUnknownUser(t *testing.T) {
+
+	app := newTestApp(t)
+
+	User, err := newTestAuthenticator(app, newTestDirectory(), true).Authenticate("Max@example.org", "geheim")
+	if err != nil {
+		t.Fatalf("expected successful login, got %s", err)
+	}
+
+	Saved := loadTestUser(app, "max@example.org")
+	if (Saved.ID != User.ID) || !Saved.Enabled || (len(Saved.Roles) != 1) || !Saved.HasRole(db.ROLE_ADMIN) {
+		t.Errorf("expected enabled admin account, got %+v", Saved)
+	}
+
+	// Entries without surname are named after their common name.
+	if Saved.LastName != "Max Muster" {
+		t.Errorf("expected last name taken from common name, got %q", Saved.LastName)
+	}
+}
+
+func TestLDAPRejectsWrongPassword(t *testing.T) {
+
+	app := newTestApp(t)
+
+	_, err := newTestAuthenticator(app, newTestDirectory(), true).Authenticate("erika@example.org", "falsch")
+	if err != ErrInvalidCredentials {
+		t.Fatalf("expected invalid credentials, got %v", err)
+	}
+
+	if User := loadTestUser(app, "erika@example.org"); User.ID != "" {
+		t.Errorf("expected no account to be created, got %+v", User)
+	}
+}
+
+func TestLDAPRejectsEmptyPassword(t *testing.T) {
+
+	app := newTestApp(t)
+	dir := newTestDirectory()
+
+	_, err := newTestAuthenticator(app, dir, true).Authenticate("erika@example.org", "")
+	if err != ErrInvalidCredentials {
+		t.Fatalf("expected invalid credentials, got %v", err)
+	}
+
+	// No anonymous bind may be attempted.
+	if dir.Dialed != 0 {
+		t.Errorf("expected directory not to be contacted, got %d connections", dir.Dialed)
+	}
+}
+
+func TestLDAPRejectsUserMissingFromDirectory(t *testing.T) {
+
+	app := newTestApp(t)
+	createTestUser(t, app, "anna@example.org", "richtig", true, db.ROLE_REVIEWER)
+
+	_, err := newTestAuthenticator(app, newTestDirectory(), true).Authenticate("anna@example.org", "richtig")
+	if err != ErrInvalidCredentials {
+		t.Fatalf("expected invalid credentials, got %v", err)
+	}
+}
+
+func TestLDAPRejectsUserWithoutGroup(t *testing.T) {
+
+	app := newTestApp(t)
+
+	_, err := newTestAuthenticator(app, newTestDirectory(), true).Authenticate("otto@example.org", "passwort")
+	if err != ErrInvalidCredentials {
+		t.Fatalf("expected invalid credentials, got %v", err)
+	}
+
+	if User := loadTestUser(app, "otto@example.org"); User.ID != "" {
+		t.Errorf("expected no account to be created, got %+v", User)
+	}
+}
+
+func TestLDAPRejectsDisabledUser(t *testing.T) {
+
+	app := newTestApp(t)
+	createTestUser(t, app, "erika@example.org", "unused", false, db.ROLE_OWNER)
+
+	_, err := newTestAuthenticator(app, newTestDirectory(), true).Authenticate("erika@example.org", "richtig")
+	if err != ErrInvalidCredentials {
+		t.Fatalf("expected invalid credentials, got %v", err)
+	}
+
+	if Saved := loadTestUser(app, "erika@example.org"); Saved.Enabled || Saved.HasRole(db.ROLE_REVIEWER) {
+		t.Errorf("expected disabled account to stay unchanged, got %+v", Saved)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/freitagsrunde/modulist/db"
+)
+
+// Functions
+
+// CheckLinks checks all links of modules and courses and
+// stores their statuses, from which broken links are
+// reported as automatic findings.
+func (app *App) CheckLinks() {
+
+	Results := app.LinkChecker.CheckAll(context.Background(), db.CollectLinks(app.DB))
+
+	Broken := 0
+	Statuses := make([]db.LinkStatus, len(Results))
+	for i, Result := range Results {
+
+		Statuses[i] = db.LinkStatus{
+			URL:     Result.URL,
+			Status:  Result.Status,
+			Error:   Result.Error,
+			Checked: Result.Checked,
+		}
+
+		if Result.Broken() {
+			Broken++
+		}
+	}
+
+	if err := db.SaveLinkStatuses(app.DB, Statuses); err != nil {
+		log.Printf("[CheckLinks] Storing statuses of links failed: %s.\n", err.Error())
+
+		return
+	}
+
+	log.Printf("[CheckLinks] Checked %d links, %d of them broken.\n", len(Results), Broken)
+}
@@ -0,0 +1,229 @@
+package linkcheck
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"net/http"
+	"net/url"
+)
+
+// Constants
+
+const (
+	// Sent along with every request, so that administrators
+	// of checked sites know who is knocking.
+	USER_AGENT = "MODULIST-Linkcheck/1.0"
+
+	// Bytes of a response body read when falling back to GET,
+	// enough to keep the connection reusable.
+	MAX_BODY_BYTES = 64 * 1024
+)
+
+// Structs
+
+// Client sends HTTP requests. *http.Client implements it,
+// tests may supply one talking to an httptest server.
+type Client interface {
+	Do(Request *http.Request) (*http.Response, error)
+}
+
+// Result is the outcome of checking one link. Status is
+// the HTTP status code of the final response, 0 if none
+// was received, in which case Error tells why.
+type Result struct {
+	URL     string
+	Status  int
+	Error   string
+	Checked time.Time
+}
+
+// Checker checks links with a limited amount of parallel
+// requests. Requests to the same host are spaced at least
+// HostDelay apart, so that no server is flooded.
+type Checker struct {
+	Client      Client
+	Concurrency int
+	Timeout     time.Duration
+	HostDelay   time.Duration
+
+	mutex sync.Mutex
+	next  map[string]time.Time
+}
+
+// Functions
+
+// NewChecker returns a checker sending requests via supplied
+// client. Concurrency is at least one.
+func NewChecker(Client Client, Concurrency int, Timeout time.Duration, HostDelay time.Duration) *Checker {
+
+	if Concurrency < 1 {
+		Concurrency = 1
+	}
+
+	return &Checker{
+		Client:      Client,
+		Concurrency: Concurrency,
+		Timeout:     Timeout,
+		HostDelay:   HostDelay,
+		next:        make(map[string]time.Time),
+	}
+}
+
+// Broken reports whether the link could not be reached
+// or answered with a client or server error.
+func (result Result) Broken() bool {
+	return (result.Error != "") || (result.Status >= 400)
+}
+
+// wait blocks until the next request to supplied host is
+// allowed and reserves that slot.
+func (checker *Checker) wait(ctx context.Context, Host string) error {
+
+	checker.mutex.Lock()
+
+	Now := time.Now()
+	Slot := checker.next[Host]
+	if Slot.Before(Now) {
+		Slot = Now
+	}
+	checker.next[Host] = Slot.Add(checker.HostDelay)
+
+	checker.mutex.Unlock()
+
+	select {
+	case <-time.After(time.Until(Slot)):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// request sends one request of supplied method and
+// returns the status code of the response.
+func (checker *Checker) request(ctx context.Context, Method string, Target *url.URL) (int, error) {
+
+	if err := checker.wait(ctx, Target.Host); err != nil {
+		return 0, err
+	}
+
+	if checker.Timeout > 0 {
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, checker.Timeout)
+		defer cancel()
+	}
+
+	Request, err := http.NewRequest(Method, Target.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	Request = Request.WithContext(ctx)
+	Request.Header.Set("User-Agent", USER_AGENT)
+
+	Response, err := checker.Client.Do(Request)
+	if err != nil {
+		return 0, err
+	}
+	defer Response.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(Response.Body, MAX_BODY_BYTES))
+
+	return Response.StatusCode, nil
+}
+
+// Check requests supplied link. HEAD is tried first, as it
+// spares transferring the page. As some servers refuse HEAD,
+// failing answers to it are verified with GET.
+func (checker *Checker) Check(ctx context.Context, Link string) Result {
+
+	Result := Result{URL: Link, Checked: time.Now()}
+
+	Target, err := url.Parse(strings.TrimSpace(Link))
+	if (err != nil) || ((Target.Scheme != "http") && (Target.Scheme != "https")) || (Target.Host == "") {
+		Result.Error = "keine gültige Webadresse"
+
+		return Result
+	}
+
+	Status, err := checker.request(ctx, http.MethodHead, Target)
+	if (err == nil) && (Status >= 400) {
+		Status, err = checker.request(ctx, http.MethodGet, Target)
+	}
+
+	if err != nil {
+		Result.Error = describeError(err)
+
+		return Result
+	}
+
+	Result.Status = Status
+
+	return Result
+}
+
+// describeError shortens errors of the HTTP client,
+// which repeat method and address of the request.
+func describeError(err error) string {
+
+	if Error, ok := err.(*url.Error); ok {
+
+		if Error.Timeout() {
+			return "Zeitüberschreitung"
+		}
+
+		return Error.Err.Error()
+	}
+
+	if err == context.DeadlineExceeded {
+		return "Zeitüberschreitung"
+	}
+
+	return err.Error()
+}
+
+// CheckAll checks supplied links with as many requests in
+// parallel as configured and returns the results in the
+// order of the links. Duplicates are checked once.
+func (checker *Checker) CheckAll(ctx context.Context, Links []string) []Result {
+
+	Unique := []string{}
+	Seen := make(map[string]bool)
+	for _, Link := range Links {
+
+		if !Seen[Link] {
+			Seen[Link] = true
+			Unique = append(Unique, Link)
+		}
+	}
+
+	Results := make([]Result, len(Unique))
+	Jobs := make(chan int)
+
+	var Workers sync.WaitGroup
+	for i := 0; i < checker.Concurrency; i++ {
+
+		Workers.Add(1)
+		go func() {
+
+			defer Workers.Done()
+
+			for Job := range Jobs {
+				Results[Job] = checker.Check(ctx, Unique[Job])
+			}
+		}()
+	}
+
+	for i := range Unique {
+		Jobs <- i
+	}
+	close(Jobs)
+
+	Workers.Wait()
+
+	return Results
+}
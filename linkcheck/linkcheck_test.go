@@ -0,0 +1,226 @@
+package linkcheck
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"net/http"
+	"net/http/httptest"
+)
+
+// Structs
+
+// recorder remembers method, path and time of
+// every request a test server received.
+type recorder struct {
+	mutex    sync.Mutex
+	Methods  []string
+	Paths    []string
+	Received []time.Time
+}
+
+// Functions
+
+func (rec *recorder) record(Request *http.Request) {
+
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+
+	rec.Methods = append(rec.Methods, Request.Method)
+	rec.Paths = append(rec.Paths, Request.URL.Path)
+	rec.Received = append(rec.Received, time.Now())
+}
+
+// newServer starts a test server answering paths with
+// the mapped status codes and 200 otherwise. Paths in
+// refuseHead answer HEAD requests with 405.
+func newServer(t *testing.T, rec *recorder, Codes map[string]int, refuseHead map[string]int) *httptest.Server {
+
+	Server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		rec.record(r)
+
+		if r.Header.Get("User-Agent") != USER_AGENT {
+			t.Errorf("expected user agent %q, got %q", USER_AGENT, r.Header.Get("User-Agent"))
+		}
+
+		if Code, ok := refuseHead[r.URL.Path]; ok && (r.Method == http.MethodHead) {
+			w.WriteHeader(Code)
+
+			return
+		}
+
+		if Code, ok := Codes[r.URL.Path]; ok {
+			w.WriteHeader(Code)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(Server.Close)
+
+	return Server
+}
+
+func TestCheckHeadSucceeds(t *testing.T) {
+
+	rec := &recorder{}
+	Server := newServer(t, rec, nil, nil)
+	Checker := NewChecker(Server.Client(), 1, time.Second, 0)
+
+	Result := Checker.Check(context.Background(), Server.URL+"/ok")
+
+	if Result.Broken() || (Result.Status != http.StatusOK) {
+		t.Fatalf("expected working link with status 200, got %+v", Result)
+	}
+
+	if (len(rec.Methods) != 1) || (rec.Methods[0] != http.MethodHead) {
+		t.Fatalf("expected a single HEAD request, got %v", rec.Methods)
+	}
+}
+
+func TestCheckFallsBackToGet(t *testing.T) {
+
+	for _, Code := range []int{http.StatusMethodNotAllowed, http.StatusNotFound} {
+
+		rec := &recorder{}
+		Server := newServer(t, rec, nil, map[string]int{"/page": Code})
+		Checker := NewChecker(Server.Client(), 1, time.Second, 0)
+
+		Result := Checker.Check(context.Background(), Server.URL+"/page")
+
+		if Result.Broken() || (Result.Status != http.StatusOK) {
+			t.Errorf("HEAD answered %d: expected GET to succeed, got %+v", Code, Result)
+		}
+
+		if (len(rec.Methods) != 2) || (rec.Methods[0] != http.MethodHead) || (rec.Methods[1] != http.MethodGet) {
+			t.Errorf("HEAD answered %d: expected HEAD followed by GET, got %v", Code, rec.Methods)
+		}
+	}
+}
+
+func TestCheckReportsErrorStatus(t *testing.T) {
+
+	rec := &recorder{}
+	Server := newServer(t, rec, map[string]int{
+		"/gone":   http.StatusGone,
+		"/failed": http.StatusInternalServerError,
+	}, nil)
+	Checker := NewChecker(Server.Client(), 1, time.Second, 0)
+
+	for Path, Code := range map[string]int{"/gone": http.StatusGone, "/failed": http.StatusInternalServerError} {
+
+		Result := Checker.Check(context.Background(), Server.URL+Path)
+
+		if !Result.Broken() || (Result.Status != Code) || (Result.Error != "") {
+			t.Errorf("expected %s to be broken with status %d, got %+v", Path, Code, Result)
+		}
+	}
+}
+
+func TestCheckInvalidLink(t *testing.T) {
+
+	Checker := NewChecker(http.DefaultClient, 1, time.Second, 0)
+
+	for _, Link := range []string{"", "ftp://example.org/file", "http://", "keine adresse"} {
+
+		Result := Checker.Check(context.Background(), Link)
+
+		if !Result.Broken() || (Result.Error != "keine gültige Webadresse") {
+			t.Errorf("expected %q to be reported as invalid, got %+v", Link, Result)
+		}
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+
+	Release := make(chan struct{})
+	Server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		select {
+		case <-Release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer Server.Close()
+	defer close(Release)
+
+	Checker := NewChecker(Server.Client(), 1, 50*time.Millisecond, 0)
+
+	Start := time.Now()
+	Result := Checker.Check(context.Background(), Server.URL+"/slow")
+
+	if !Result.Broken() || (Result.Status != 0) || (Result.Error != "Zeitüberschreitung") {
+		t.Fatalf("expected timeout, got %+v", Result)
+	}
+
+	// A timed out HEAD request must not be retried with GET.
+	if Elapsed := time.Since(Start); Elapsed > time.Second {
+		t.Fatalf("expected check to give up after timeout, took %s", Elapsed)
+	}
+}
+
+func TestCheckHostDelay(t *testing.T) {
+
+	Delay := 100 * time.Millisecond
+
+	rec := &recorder{}
+	Server := newServer(t, rec, nil, nil)
+	Checker := NewChecker(Server.Client(), 3, time.Second, Delay)
+
+	Checker.CheckAll(context.Background(), []string{Server.URL + "/a", Server.URL + "/b", Server.URL + "/c"})
+
+	if len(rec.Received) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(rec.Received))
+	}
+
+	// Allow for a little scheduling jitter.
+	for i := 1; i < len(rec.Received); i++ {
+
+		if Gap := rec.Received[i].Sub(rec.Received[i-1]); Gap < (Delay - 10*time.Millisecond) {
+			t.Errorf("expected requests to the same host at least %s apart, got %s", Delay, Gap)
+		}
+	}
+}
+
+func TestCheckAllDeduplicatesAndKeepsOrder(t *testing.T) {
+
+	rec := &recorder{}
+	Server := newServer(t, rec, map[string]int{"/missing": http.StatusNotFound}, nil)
+	Checker := NewChecker(Server.Client(), 4, time.Second, 0)
+
+	Links := []string{
+		Server.URL + "/c",
+		Server.URL + "/missing",
+		Server.URL + "/a",
+		Server.URL + "/c",
+		Server.URL + "/b",
+		Server.URL + "/a",
+	}
+
+	Results := Checker.CheckAll(context.Background(), Links)
+
+	Expected := []string{Server.URL + "/c", Server.URL + "/missing", Server.URL + "/a", Server.URL + "/b"}
+	if len(Results) != len(Expected) {
+		t.Fatalf("expected %d results, got %d", len(Expected), len(Results))
+	}
+
+	for i, Link := range Expected {
+
+		if Results[i].URL != Link {
+			t.Errorf("expected result %d to be %s, got %s", i, Link, Results[i].URL)
+		}
+
+		if Results[i].Broken() != (Link == Server.URL+"/missing") {
+			t.Errorf("unexpected result for %s: %+v", Link, Results[i])
+		}
+	}
+
+	// Missing link costs HEAD and GET, all others one HEAD.
+	if len(rec.Paths) != 5 {
+		t.Errorf("expected every unique link to be requested once, got %v", rec.Paths)
+	}
+}
@@ -0,0 +1,66 @@
+package lint
+
+import (
+	"sort"
+
+	"github.com/freitagsrunde/modulist/db"
+)
+
+// Structs
+
+// Finding is a problem of a module description a rule
+// found. Category and severity follow the ones of
+// feedback, so that findings can become feedback.
+type Finding struct {
+	Rule     string
+	Category int
+	Severity string
+	Message  string
+}
+
+// Rule checks one aspect of a module description.
+// Courses, working efforts, exam elements and link
+// statuses of the module have to be loaded for rules
+// to see them.
+type Rule struct {
+	Name        string
+	Description string
+	Check       func(Module db.Module) []Finding
+}
+
+// Functions
+
+// SeverityRank returns the position of supplied severity
+// from least to most severe, -1 for unknown severities.
+func SeverityRank(Severity string) int {
+
+	for i, Known := range db.Severities() {
+
+		if Known == Severity {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Check runs all rules over supplied module and returns
+// the findings, most severe first, then by category.
+func Check(Module db.Module) []Finding {
+
+	Findings := []Finding{}
+	for _, Rule := range Rules() {
+		Findings = append(Findings, Rule.Check(Module)...)
+	}
+
+	sort.SliceStable(Findings, func(i, j int) bool {
+
+		if Findings[i].Severity != Findings[j].Severity {
+			return SeverityRank(Findings[i].Severity) > SeverityRank(Findings[j].Severity)
+		}
+
+		return Findings[i].Category < Findings[j].Category
+	})
+
+	return Findings
+}
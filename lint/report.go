@@ -0,0 +1,158 @@
+package lint
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"encoding/csv"
+	"encoding/json"
+
+	"github.com/freitagsrunde/modulist/db"
+)
+
+// Structs
+
+// ModuleReport holds all findings on one module.
+// ID is the database ID, Number and Version the
+// identification of the module in the catalogue.
+type ModuleReport struct {
+	ID       int
+	Number   int
+	Version  int
+	Title    string
+	Findings []Finding
+}
+
+// Functions
+
+// CheckAll runs all rules over supplied modules and
+// returns one report per module, ordered by amount of
+// findings and, for equal amounts, by the most severe
+// finding, so that modules needing most work come first.
+func CheckAll(Modules []db.Module) []ModuleReport {
+
+	Reports := make([]ModuleReport, len(Modules))
+
+	for i, Module := range Modules {
+
+		Reports[i] = ModuleReport{
+			ID:       Module.ID,
+			Number:   Module.ModuleID,
+			Version:  Module.Version,
+			Title:    Module.Title.String,
+			Findings: Check(Module),
+		}
+	}
+
+	sort.SliceStable(Reports, func(i, j int) bool {
+
+		if len(Reports[i].Findings) != len(Reports[j].Findings) {
+			return len(Reports[i].Findings) > len(Reports[j].Findings)
+		}
+
+		return Reports[i].MaxSeverity() > Reports[j].MaxSeverity()
+	})
+
+	return Reports
+}
+
+// MaxSeverity returns the rank of the most severe finding
+// of this report, -1 for reports without findings.
+func (report ModuleReport) MaxSeverity() int {
+
+	Max := -1
+	for _, Finding := range report.Findings {
+
+		if Rank := SeverityRank(Finding.Severity); Rank > Max {
+			Max = Rank
+		}
+	}
+
+	return Max
+}
+
+// CountBySeverity returns the amount of findings of this
+// report per severity.
+func (report ModuleReport) CountBySeverity() map[string]int {
+
+	Counts := make(map[string]int)
+	for _, Finding := range report.Findings {
+		Counts[Finding.Severity]++
+	}
+
+	return Counts
+}
+
+// Exceeds reports whether any finding of supplied
+// reports is at least as severe as supplied severity.
+func Exceeds(Reports []ModuleReport, Severity string) bool {
+
+	Threshold := SeverityRank(Severity)
+	if Threshold < 0 {
+		return false
+	}
+
+	for _, Report := range Reports {
+
+		if Report.MaxSeverity() >= Threshold {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WriteTable writes all findings of supplied reports
+// as table aligned for reading in a terminal.
+func WriteTable(w io.Writer, Reports []ModuleReport) error {
+
+	Table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	Titles := db.CategoryTitles()
+
+	fmt.Fprintln(Table, "MODUL\tVERSION\tTITEL\tSCHWEREGRAD\tREGEL\tKATEGORIE\tBEFUND")
+
+	for _, Report := range Reports {
+
+		for _, Finding := range Report.Findings {
+			fmt.Fprintf(Table, "%d\t%d\t%s\t%s\t%s\t%s\t%s\n", Report.Number, Report.Version, Report.Title,
+				Finding.Severity, Finding.Rule, Titles[Finding.Category], Finding.Message)
+		}
+	}
+
+	return Table.Flush()
+}
+
+// WriteJSON writes supplied reports as JSON array,
+// including modules without findings.
+func WriteJSON(w io.Writer, Reports []ModuleReport) error {
+
+	Encoder := json.NewEncoder(w)
+	Encoder.SetIndent("", "  ")
+
+	return Encoder.Encode(Reports)
+}
+
+// WriteCSV writes all findings of supplied reports
+// as CSV, one finding per row.
+func WriteCSV(w io.Writer, Reports []ModuleReport) error {
+
+	Writer := csv.NewWriter(w)
+	Titles := db.CategoryTitles()
+
+	Writer.Write([]string{"module", "version", "title", "severity", "rule", "category", "message"})
+
+	for _, Report := range Reports {
+
+		for _, Finding := range Report.Findings {
+			Writer.Write([]string{strconv.Itoa(Report.Number), strconv.Itoa(Report.Version), Report.Title,
+				Finding.Severity, Finding.Rule, Titles[Finding.Category], Finding.Message})
+		}
+	}
+
+	Writer.Flush()
+
+	return Writer.Error()
+}
@@ -0,0 +1,247 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"database/sql"
+
+	"github.com/freitagsrunde/modulist/db"
+	"github.com/freitagsrunde/modulist/workload"
+)
+
+// Constants
+
+const (
+	// Points all exam elements of a portfolio exam sum up to.
+	PORTFOLIO_POINTS = 100
+
+	// Abbreviation of portfolio exams.
+	EXAMINATION_PORTFOLIO = "P"
+)
+
+// Functions
+
+// Rules returns all rules checked for each module.
+func Rules() []Rule {
+
+	return []Rule{
+		{"workload-ects", "Summe des Arbeitsaufwands entspricht den Leistungspunkten", checkWorkload},
+		{"workload-efforts", "Gesamtstunden entsprechen Multiplikator mal Stunden", checkWorkloadEfforts},
+		{"portfolio-points", "Prüfungselemente einer Portfolioprüfung ergeben 100 Punkte", checkPortfolioPoints},
+		{"examination-elements", "Prüfungsform passt zu den Prüfungselementen", checkExaminationElements},
+		{"english-texts", "Lernergebnisse und Lehrinhalte liegen auf Englisch vor", checkEnglishTexts},
+		{"literature", "Literaturhinweise sind angegeben", checkLiterature},
+		{"participant-registration", "Begrenzte Teilnahme nennt Anmeldeformalitäten", checkParticipantRegistration},
+		{"broken-links", "Links der Modulbeschreibung sind erreichbar", checkLinks},
+	}
+}
+
+// blank reports whether supplied text is missing or
+// contains nothing but whitespace.
+func blank(Text sql.NullString) bool {
+	return !Text.Valid || (strings.TrimSpace(Text.String) == "")
+}
+
+// checkWorkload compares the total working effort with
+// the hours the credits of the module stand for.
+func checkWorkload(Module db.Module) []Finding {
+
+	if len(Module.WorkingEfforts) == 0 {
+
+		return []Finding{{
+			Rule:     "workload-ects",
+			Category: db.CATEGORY_WORKING_EFFORT,
+			Severity: db.SEVERITY_MAJOR,
+			Message:  "Der Arbeitsaufwand ist nicht aufgeschlüsselt.",
+		}}
+	}
+
+	Summary := workload.Calculate(Module)
+	if !Summary.MatchesECTS() {
+
+		return []Finding{{
+			Rule:     "workload-ects",
+			Category: db.CATEGORY_WORKING_EFFORT,
+			Severity: db.SEVERITY_MAJOR,
+			Message: fmt.Sprintf("Der Arbeitsaufwand summiert sich auf %g Stunden, %d Leistungspunkte entsprechen aber %g Stunden.",
+				Summary.TotalHours, Module.ECTS, Summary.ExpectedHours),
+		}}
+	}
+
+	return nil
+}
+
+// checkWorkloadEfforts reports working efforts whose total
+// is not their multiplier times their hours.
+func checkWorkloadEfforts(Module db.Module) []Finding {
+
+	Findings := []Finding{}
+
+	for _, Mismatch := range workload.Calculate(Module).Mismatches {
+
+		Findings = append(Findings, Finding{
+			Rule:     "workload-efforts",
+			Category: db.CATEGORY_WORKING_EFFORT,
+			Severity: db.SEVERITY_MINOR,
+			Message: fmt.Sprintf("„%s“ (%s): %g × %gh ergibt %gh, angegeben sind aber %gh.",
+				Mismatch.Description, Mismatch.Category, Mismatch.Multiplier, Mismatch.Hours, Mismatch.Expected, Mismatch.Total),
+		})
+	}
+
+	return Findings
+}
+
+// checkPortfolioPoints makes sure the exam elements of
+// a portfolio exam are weighted to a total of 100 points.
+func checkPortfolioPoints(Module db.Module) []Finding {
+
+	if (Module.TypeOfExamination != EXAMINATION_PORTFOLIO) || (len(Module.ExamElements) == 0) {
+		return nil
+	}
+
+	Points := 0
+	for _, Element := range Module.ExamElements {
+		Points += Element.Points
+	}
+
+	if Points != PORTFOLIO_POINTS {
+
+		return []Finding{{
+			Rule:     "portfolio-points",
+			Category: db.CATEGORY_EXAMINATION,
+			Severity: db.SEVERITY_MAJOR,
+			Message:  fmt.Sprintf("Die Prüfungselemente der Portfolioprüfung ergeben %d statt %d Punkte.", Points, PORTFOLIO_POINTS),
+		}}
+	}
+
+	return nil
+}
+
+// checkExaminationElements makes sure exam elements are
+// listed for portfolio exams and for portfolio exams only.
+func checkExaminationElements(Module db.Module) []Finding {
+
+	if (Module.TypeOfExamination == EXAMINATION_PORTFOLIO) && (len(Module.ExamElements) == 0) {
+
+		return []Finding{{
+			Rule:     "examination-elements",
+			Category: db.CATEGORY_EXAMINATION,
+			Severity: db.SEVERITY_MAJOR,
+			Message:  "Für die Portfolioprüfung sind keine Prüfungselemente angegeben.",
+		}}
+	}
+
+	if (Module.TypeOfExamination != EXAMINATION_PORTFOLIO) && (len(Module.ExamElements) > 0) {
+
+		return []Finding{{
+			Rule:     "examination-elements",
+			Category: db.CATEGORY_EXAMINATION,
+			Severity: db.SEVERITY_MINOR,
+			Message:  "Es sind Prüfungselemente angegeben, obwohl es sich nicht um eine Portfolioprüfung handelt.",
+		}}
+	}
+
+	return nil
+}
+
+// checkEnglishTexts reports learning outcomes and teaching
+// contents given in German but missing in English.
+func checkEnglishTexts(Module db.Module) []Finding {
+
+	Findings := []Finding{}
+
+	if !blank(Module.LearningOutcomes) && blank(Module.LearningOutcomesEnglish) {
+
+		Findings = append(Findings, Finding{
+			Rule:     "english-texts",
+			Category: db.CATEGORY_LEARNING_OUTCOMES,
+			Severity: db.SEVERITY_MINOR,
+			Message:  "Die Lernergebnisse fehlen in englischer Sprache.",
+		})
+	}
+
+	if !blank(Module.TeachingContents) && blank(Module.TeachingContentsEnglish) {
+
+		Findings = append(Findings, Finding{
+			Rule:     "english-texts",
+			Category: db.CATEGORY_TEACHING_CONTENTS,
+			Severity: db.SEVERITY_MINOR,
+			Message:  "Die Lehrinhalte fehlen in englischer Sprache.",
+		})
+	}
+
+	return Findings
+}
+
+// checkLiterature reports modules without literature.
+func checkLiterature(Module db.Module) []Finding {
+
+	if strings.TrimSpace(Module.Literature) == "" {
+
+		return []Finding{{
+			Rule:     "literature",
+			Category: db.CATEGORY_LITERATURE,
+			Severity: db.SEVERITY_HINT,
+			Message:  "Es sind keine Literaturhinweise angegeben.",
+		}}
+	}
+
+	return nil
+}
+
+// checkParticipantRegistration reports modules limiting
+// participants without telling how to register.
+func checkParticipantRegistration(Module db.Module) []Finding {
+
+	if Module.ParticipantLimitation.Valid && (Module.ParticipantLimitation.Int64 > 0) && blank(Module.RegistrationFormalities) {
+
+		return []Finding{{
+			Rule:     "participant-registration",
+			Category: db.CATEGORY_REGISTRATION_FORMALITIES,
+			Severity: db.SEVERITY_MINOR,
+			Message: fmt.Sprintf("Die Teilnahme ist auf %d Personen begrenzt, Anmeldeformalitäten sind aber nicht angegeben.",
+				Module.ParticipantLimitation.Int64),
+		}}
+	}
+
+	return nil
+}
+
+// checkLinks reports links of the module and its courses
+// the last check of the link checker found broken. Links
+// not checked yet are not reported.
+func checkLinks(Module db.Module) []Finding {
+
+	Findings := []Finding{}
+
+	report := func(Link string, Category int, Place string) {
+
+		Status, found := Module.LinkStatuses[strings.TrimSpace(Link)]
+		if !found || !Status.Broken() {
+			return
+		}
+
+		Findings = append(Findings, Finding{
+			Rule:     "broken-links",
+			Category: Category,
+			Severity: db.SEVERITY_MINOR,
+			Message:  fmt.Sprintf("Der Link %s „%s“ ist nicht erreichbar (%s).", Place, Link, Status.Describe()),
+		})
+	}
+
+	if Module.Website.Valid {
+		report(Module.Website.String, db.CATEGORY_HEADER, "zur Website")
+	}
+
+	report(Module.URL, db.CATEGORY_HEADER, "zur Modulbeschreibung")
+
+	for _, Course := range Module.Courses {
+
+		if Course.CourseURL.Valid {
+			report(Course.CourseURL.String, db.CATEGORY_COURSES, fmt.Sprintf("der Lehrveranstaltung „%s“", Course.Title))
+		}
+	}
+
+	return Findings
+}
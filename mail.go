@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"crypto/tls"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/smtp"
+)
+
+// Structs
+
+// Mailer sends plain text mails. It is satisfied by
+// SMTPMailer and allows to replace the mail server by
+// a stub, e.g. LogMailer during development.
+type Mailer interface {
+	Send(To []string, Subject string, Body string) error
+}
+
+// SMTPMailer delivers mails via the SMTP server
+// configured in the .env file. Port 465 is spoken
+// to via implicit TLS, all other ports are upgraded
+// via StartTLS if the server offers it.
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	From     string
+}
+
+// LogMailer only writes mails to the log.
+type LogMailer struct{}
+
+// Functions
+
+// InitMailer reads the mail server configuration
+// from the .env file and prepares a Mailer with it.
+func InitMailer() Mailer {
+
+	enabled, err := strconv.ParseBool(os.Getenv("MAIL_ENABLED"))
+	if err != nil {
+		log.Fatal("[InitMailer] Unrecognized MAIL_ENABLED indicator in .env, expecting bool. Terminating.")
+	}
+
+	// Without a mail server, mails only end up in the log.
+	if !enabled {
+		log.Println("[InitMailer] Sending mails is disabled, mails will only be logged.")
+
+		return LogMailer{}
+	}
+
+	from := os.Getenv("MAIL_FROM")
+	if _, err := mail.ParseAddress(from); err != nil {
+		log.Fatal("[InitMailer] Could not parse MAIL_FROM from .env file, expecting a mail address. Terminating.")
+	}
+
+	if _, err := strconv.Atoi(os.Getenv("MAIL_PORT")); err != nil {
+		log.Fatal("[InitMailer] Could not load MAIL_PORT from .env file. Missing or not an integer?")
+	}
+
+	return &SMTPMailer{
+		Host:     os.Getenv("MAIL_IP"),
+		Port:     os.Getenv("MAIL_PORT"),
+		User:     os.Getenv("MAIL_USER"),
+		Password: os.Getenv("MAIL_PASSWORD"),
+		From:     from,
+	}
+}
+
+// ComposeMail builds a complete plain text mail
+// including headers, ready to be handed to a server.
+func ComposeMail(From string, To []string, Subject string, Body string) ([]byte, error) {
+
+	// Line breaks in headers would allow to inject further headers.
+	Subject = strings.NewReplacer("\r", " ", "\n", " ").Replace(Subject)
+
+	for _, recipient := range To {
+
+		if _, err := mail.ParseAddress(recipient); err != nil {
+			return nil, fmt.Errorf("invalid recipient '%s': %s", recipient, err)
+		}
+	}
+
+	var msg bytes.Buffer
+
+	fmt.Fprintf(&msg, "From: %s\r\n", From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprintf(&msg, "Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	body := quotedprintable.NewWriter(&msg)
+	if _, err := body.Write([]byte(Body)); err != nil {
+		return nil, err
+	}
+
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+
+	return msg.Bytes(), nil
+}
+
+// Send implements Mailer for SMTP servers.
+func (m *SMTPMailer) Send(To []string, Subject string, Body string) error {
+
+	msg, err := ComposeMail(m.From, To, Subject, Body)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(m.Host, m.Port)
+	tlsConfig := &tls.Config{ServerName: m.Host}
+
+	var client *smtp.Client
+
+	if m.Port == "465" {
+
+		// Submission via implicit TLS.
+		conn, err := tls.Dial("tcp", addr, tlsConfig)
+		if err != nil {
+			return fmt.Errorf("connecting to mail server failed: %s", err)
+		}
+
+		client, err = smtp.NewClient(conn, m.Host)
+		if err != nil {
+			conn.Close()
+
+			return fmt.Errorf("greeting mail server failed: %s", err)
+		}
+	} else {
+
+		client, err = smtp.Dial(addr)
+		if err != nil {
+			return fmt.Errorf("connecting to mail server failed: %s", err)
+		}
+
+		if ok, _ := client.Extension("STARTTLS"); ok {
+
+			if err := client.StartTLS(tlsConfig); err != nil {
+				client.Close()
+
+				return fmt.Errorf("upgrading connection to mail server failed: %s", err)
+			}
+		}
+	}
+	defer client.Close()
+
+	// Authenticate only if configured. PlainAuth refuses
+	// to send credentials over unencrypted connections.
+	if m.User != "" {
+
+		if err := client.Auth(smtp.PlainAuth("", m.User, m.Password, m.Host)); err != nil {
+			return fmt.Errorf("authenticating at mail server failed: %s", err)
+		}
+	}
+
+	if err := client.Mail(m.From); err != nil {
+		return fmt.Errorf("mail server rejected sender: %s", err)
+	}
+
+	for _, recipient := range To {
+
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("mail server rejected recipient '%s': %s", recipient, err)
+		}
+	}
+
+	data, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("mail server refused data: %s", err)
+	}
+
+	if _, err := data.Write(msg); err != nil {
+		return fmt.Errorf("writing mail failed: %s", err)
+	}
+
+	if err := data.Close(); err != nil {
+		return fmt.Errorf("mail server did not accept mail: %s", err)
+	}
+
+	return client.Quit()
+}
+
+// Send implements Mailer by logging the mail.
+func (m LogMailer) Send(To []string, Subject string, Body string) error {
+
+	log.Printf("[LogMailer] Mail to '%s' with subject '%s':\n%s\n", strings.Join(To, ", "), Subject, Body)
+
+	return nil
+}
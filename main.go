@@ -4,17 +4,32 @@ package main
 
 import (
 	"fmt"
+	"os"
 )
 
 // Functions
 
 func main() {
 
+	// Run plausibility checks from the command line instead of serving.
+	if (len(os.Args) > 1) && (os.Args[1] == "lint") {
+		os.Exit(RunLint(os.Args[2:]))
+	}
+
 	// Init app.
 	app := InitApp()
 
+	// Run periodic jobs, e.g. mailing digests, in the background.
+	app.StartJobs()
+
 	// Run MODULIST either with or without TLS.
 	if app.TLS {
+
+		// Optionally redirect plain HTTP requests to HTTPS.
+		if app.RedirectPort != "" {
+			go app.RedirectToHTTPS()
+		}
+
 		app.Router.RunTLS(fmt.Sprintf("%s:%s", app.IP, app.Port), app.TLSCertFile, app.TLSKeyFile)
 	} else {
 		app.Router.Run(fmt.Sprintf("%s:%s", app.IP, app.Port))
@@ -0,0 +1,494 @@
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"html/template"
+)
+
+// Constants
+
+const (
+	// Kinds of blocks a comment consists of.
+	blockParagraph = iota
+	blockQuote
+	blockList
+	blockOrderedList
+)
+
+const (
+	// Kinds of inline elements within blocks.
+	inlineText = iota
+	inlineBreak
+	inlineStrong
+	inlineEmphasis
+	inlineCode
+	inlineLink
+)
+
+// Structs
+
+// block is a paragraph, a quote containing further
+// blocks or a list whose items are lists of inlines.
+type block struct {
+	Kind    int
+	Inlines []inline
+	Blocks  []block
+	Items   [][]inline
+}
+
+// inline is a run of text, possibly formatted.
+type inline struct {
+	Kind     int
+	Text     string
+	Href     string
+	Children []inline
+}
+
+// Variables
+
+var (
+	unorderedItem = regexp.MustCompile(`^[-*+][ \t]+`)
+	orderedItem   = regexp.MustCompile(`^[0-9]{1,9}[.)][ \t]+`)
+	quoteMarker   = regexp.MustCompile(`^>[ \t]?`)
+)
+
+// Functions
+
+// ToHTML renders supplied comment written in the
+// supported subset of Markdown as sanitized HTML.
+// Supported are emphasis, strong emphasis, inline
+// code, links, lists and quotes. Everything else is
+// displayed as the text it is.
+func ToHTML(Source string) template.HTML {
+
+	var HTML strings.Builder
+	renderBlocks(&HTML, parseBlocks(splitLines(Source)))
+
+	return template.HTML(Sanitize(HTML.String()))
+}
+
+// ToPlain renders supplied comment as plain text without
+// formatting characters, e.g. to be included in mails.
+// Lists and quotes keep their markers, links are
+// followed by their target.
+func ToPlain(Source string) string {
+
+	Parts := []string{}
+	for _, Block := range parseBlocks(splitLines(Source)) {
+		Parts = append(Parts, plainBlock(Block))
+	}
+
+	return strings.Join(Parts, "\n\n")
+}
+
+// splitLines normalizes line breaks and splits into lines.
+func splitLines(Source string) []string {
+
+	Source = strings.Replace(strings.Replace(Source, "\r\n", "\n", -1), "\r", "\n", -1)
+
+	return strings.Split(strings.TrimSpace(Source), "\n")
+}
+
+// parseBlocks groups lines into paragraphs, quotes and
+// lists. Blank lines end paragraphs and lists, lines
+// indented below a list item continue that item.
+func parseBlocks(Lines []string) []block {
+
+	Blocks := []block{}
+
+	for i := 0; i < len(Lines); {
+
+		Line := Lines[i]
+		Trimmed := strings.TrimSpace(Line)
+
+		switch {
+
+		case Trimmed == "":
+			i++
+
+		case quoteMarker.MatchString(Trimmed):
+
+			Quoted := []string{}
+			for (i < len(Lines)) && quoteMarker.MatchString(strings.TrimSpace(Lines[i])) {
+				Quoted = append(Quoted, quoteMarker.ReplaceAllString(strings.TrimSpace(Lines[i]), ""))
+				i++
+			}
+
+			Blocks = append(Blocks, block{Kind: blockQuote, Blocks: parseBlocks(Quoted)})
+
+		case unorderedItem.MatchString(Trimmed) || orderedItem.MatchString(Trimmed):
+
+			Marker := unorderedItem
+			List := block{Kind: blockList}
+			if !unorderedItem.MatchString(Trimmed) {
+				Marker = orderedItem
+				List.Kind = blockOrderedList
+			}
+
+			Items := [][]string{}
+			for i < len(Lines) {
+
+				Current := strings.TrimSpace(Lines[i])
+
+				if Marker.MatchString(Current) {
+					Items = append(Items, []string{Marker.ReplaceAllString(Current, "")})
+				} else if (Current != "") && (len(Lines[i]) > len(strings.TrimLeftFunc(Lines[i], unicode.IsSpace))) {
+					Items[len(Items)-1] = append(Items[len(Items)-1], Current)
+				} else {
+					break
+				}
+
+				i++
+			}
+
+			for _, Item := range Items {
+				List.Items = append(List.Items, parseLines(Item))
+			}
+
+			Blocks = append(Blocks, List)
+
+		default:
+
+			Paragraph := []string{}
+			for i < len(Lines) {
+
+				Current := strings.TrimSpace(Lines[i])
+				if (Current == "") || quoteMarker.MatchString(Current) || unorderedItem.MatchString(Current) || orderedItem.MatchString(Current) {
+					break
+				}
+
+				Paragraph = append(Paragraph, Current)
+				i++
+			}
+
+			Blocks = append(Blocks, block{Kind: blockParagraph, Inlines: parseLines(Paragraph)})
+		}
+	}
+
+	return Blocks
+}
+
+// parseLines parses the inlines of lines belonging to one
+// block, keeping the line breaks between them.
+func parseLines(Lines []string) []inline {
+
+	Inlines := []inline{}
+
+	for i, Line := range Lines {
+
+		if i > 0 {
+			Inlines = append(Inlines, inline{Kind: inlineBreak})
+		}
+
+		Inlines = append(Inlines, parseInlines(Line)...)
+	}
+
+	return Inlines
+}
+
+// allowedLink reports whether a link target may be
+// displayed. Only web and mail addresses are allowed.
+func allowedLink(Href string) bool {
+
+	Lower := strings.ToLower(Href)
+
+	return strings.HasPrefix(Lower, "http://") || strings.HasPrefix(Lower, "https://") || strings.HasPrefix(Lower, "mailto:")
+}
+
+// isWordRune reports whether r belongs to a word.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// indexAt returns the index of the first occurrence of
+// Delimiter in Runes at or after From, -1 if there is none.
+func indexAt(Runes []rune, From int, Delimiter []rune) int {
+
+	for i := From; (i + len(Delimiter)) <= len(Runes); i++ {
+
+		if hasPrefixAt(Runes, i, Delimiter) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// hasPrefixAt reports whether Runes continue with
+// Delimiter at supplied index.
+func hasPrefixAt(Runes []rune, i int, Delimiter []rune) bool {
+
+	if (i + len(Delimiter)) > len(Runes) {
+		return false
+	}
+
+	for j, r := range Delimiter {
+
+		if Runes[i+j] != r {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseInlines parses emphasis, code and links within one
+// line. Delimiters without a matching closing delimiter
+// are kept as text.
+func parseInlines(Line string) []inline {
+
+	Runes := []rune(Line)
+	Inlines := []inline{}
+	Text := []rune{}
+
+	flush := func() {
+		if len(Text) > 0 {
+			Inlines = append(Inlines, inline{Kind: inlineText, Text: string(Text)})
+			Text = []rune{}
+		}
+	}
+
+	// Remember where each delimiter was last searched from and
+	// found, so that long lines are not scanned again and again.
+	type search struct {
+		From int
+		At   int
+	}
+	searches := make(map[string]search)
+
+	find := func(From int, Delimiter string) int {
+
+		if Last, ok := searches[Delimiter]; ok && (From >= Last.From) && ((Last.At < 0) || (Last.At >= From)) {
+			return Last.At
+		}
+
+		At := indexAt(Runes, From, []rune(Delimiter))
+		searches[Delimiter] = search{From: From, At: At}
+
+		return At
+	}
+
+	for i := 0; i < len(Runes); i++ {
+
+		r := Runes[i]
+
+		// Backslashes escape formatting characters.
+		if (r == '\\') && ((i + 1) < len(Runes)) && strings.ContainsRune("\\`*_[]()>#+-.!", Runes[i+1]) {
+			Text = append(Text, Runes[i+1])
+			i++
+
+			continue
+		}
+
+		switch {
+
+		case r == '`':
+
+			if End := find((i + 1), "`"); End > (i + 1) {
+
+				flush()
+				Inlines = append(Inlines, inline{Kind: inlineCode, Text: string(Runes[(i + 1):End])})
+				i = End
+
+				continue
+			}
+
+		case hasPrefixAt(Runes, i, []rune("**")):
+
+			if End := find((i + 2), "**"); End > (i + 2) {
+
+				flush()
+				Inlines = append(Inlines, inline{Kind: inlineStrong, Children: parseInlines(string(Runes[(i + 2):End]))})
+				i = End + 1
+
+				continue
+			}
+
+		case (r == '*') || ((r == '_') && ((i == 0) || !isWordRune(Runes[i-1]))):
+
+			if End := find((i + 1), string(r)); (End > (i + 1)) && !unicode.IsSpace(Runes[i+1]) {
+
+				flush()
+				Inlines = append(Inlines, inline{Kind: inlineEmphasis, Children: parseInlines(string(Runes[(i + 1):End]))})
+				i = End
+
+				continue
+			}
+
+		case r == '[':
+
+			if Label := find(i, "]("); Label > (i + 1) {
+
+				Href := ""
+				Close := find((Label + 2), ")")
+				if Close > (Label + 2) {
+					Href = string(Runes[(Label + 2):Close])
+				}
+
+				if allowedLink(Href) && !strings.ContainsAny(Href, " \t") {
+
+					flush()
+					Inlines = append(Inlines, inline{Kind: inlineLink, Href: Href, Children: parseInlines(string(Runes[(i + 1):Label]))})
+					i = Close
+
+					continue
+				}
+			}
+		}
+
+		Text = append(Text, r)
+	}
+
+	flush()
+
+	return Inlines
+}
+
+// renderBlocks writes the HTML of supplied blocks.
+func renderBlocks(HTML *strings.Builder, Blocks []block) {
+
+	for _, Block := range Blocks {
+
+		switch Block.Kind {
+
+		case blockQuote:
+			HTML.WriteString("<blockquote>")
+			renderBlocks(HTML, Block.Blocks)
+			HTML.WriteString("</blockquote>")
+
+		case blockList, blockOrderedList:
+
+			Tag := "ul"
+			if Block.Kind == blockOrderedList {
+				Tag = "ol"
+			}
+
+			HTML.WriteString("<" + Tag + ">")
+			for _, Item := range Block.Items {
+				HTML.WriteString("<li>")
+				renderInlines(HTML, Item)
+				HTML.WriteString("</li>")
+			}
+			HTML.WriteString("</" + Tag + ">")
+
+		default:
+			HTML.WriteString("<p>")
+			renderInlines(HTML, Block.Inlines)
+			HTML.WriteString("</p>")
+		}
+	}
+}
+
+// renderInlines writes the HTML of supplied inlines.
+// All text is escaped.
+func renderInlines(HTML *strings.Builder, Inlines []inline) {
+
+	for _, Inline := range Inlines {
+
+		switch Inline.Kind {
+
+		case inlineBreak:
+			HTML.WriteString("<br>")
+
+		case inlineStrong:
+			HTML.WriteString("<strong>")
+			renderInlines(HTML, Inline.Children)
+			HTML.WriteString("</strong>")
+
+		case inlineEmphasis:
+			HTML.WriteString("<em>")
+			renderInlines(HTML, Inline.Children)
+			HTML.WriteString("</em>")
+
+		case inlineCode:
+			HTML.WriteString("<code>" + template.HTMLEscapeString(Inline.Text) + "</code>")
+
+		case inlineLink:
+			fmt.Fprintf(HTML, "<a href=\"%s\">", template.HTMLEscapeString(Inline.Href))
+			renderInlines(HTML, Inline.Children)
+			HTML.WriteString("</a>")
+
+		default:
+			HTML.WriteString(template.HTMLEscapeString(Inline.Text))
+		}
+	}
+}
+
+// plainBlock renders one block as plain text.
+func plainBlock(Block block) string {
+
+	switch Block.Kind {
+
+	case blockQuote:
+
+		Parts := []string{}
+		for _, Quoted := range Block.Blocks {
+			Parts = append(Parts, plainBlock(Quoted))
+		}
+
+		Lines := strings.Split(strings.Join(Parts, "\n\n"), "\n")
+		for i := range Lines {
+			Lines[i] = strings.TrimRight(("> " + Lines[i]), " ")
+		}
+
+		return strings.Join(Lines, "\n")
+
+	case blockList, blockOrderedList:
+
+		Lines := []string{}
+		for i, Item := range Block.Items {
+
+			Marker := "- "
+			if Block.Kind == blockOrderedList {
+				Marker = fmt.Sprintf("%d. ", (i + 1))
+			}
+
+			Indent := strings.Repeat(" ", len(Marker))
+			Lines = append(Lines, Marker+strings.Replace(plainInlines(Item), "\n", ("\n"+Indent), -1))
+		}
+
+		return strings.Join(Lines, "\n")
+
+	default:
+		return plainInlines(Block.Inlines)
+	}
+}
+
+// plainInlines renders inlines as plain text.
+func plainInlines(Inlines []inline) string {
+
+	var Text strings.Builder
+
+	for _, Inline := range Inlines {
+
+		switch Inline.Kind {
+
+		case inlineBreak:
+			Text.WriteString("\n")
+
+		case inlineStrong, inlineEmphasis:
+			Text.WriteString(plainInlines(Inline.Children))
+
+		case inlineCode:
+			Text.WriteString("\"" + Inline.Text + "\"")
+
+		case inlineLink:
+
+			Label := plainInlines(Inline.Children)
+			Text.WriteString(Label)
+
+			if Label != Inline.Href {
+				Text.WriteString(" (" + Inline.Href + ")")
+			}
+
+		default:
+			Text.WriteString(Inline.Text)
+		}
+	}
+
+	return Text.String()
+}
@@ -0,0 +1,73 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToHTML(t *testing.T) {
+
+	Cases := []struct {
+		Source   string
+		Expected string
+	}{
+		{"Nur Text", "<p>Nur Text</p>"},
+		{"**fett** und *kursiv* und _auch_", "<p><strong>fett</strong> und <em>kursiv</em> und <em>auch</em></p>"},
+		{"`a < b`", "<p><code>a &lt; b</code></p>"},
+		{"snake_case_name", "<p>snake_case_name</p>"},
+		{"* nur ein Stern", "<ul><li>nur ein Stern</li></ul>"},
+		{"2 * 3 * 4", "<p>2 * 3 * 4</p>"},
+		{"offen ** und ` und [", "<p>offen ** und ` und [</p>"},
+		{`\*kein\* Stern`, "<p>*kein* Stern</p>"},
+		{"Zeile 1\nZeile 2", "<p>Zeile 1<br>Zeile 2</p>"},
+		{"- a\n- b\n\n> Zitat\n\n1. x", "<ul><li>a</li><li>b</li></ul><blockquote><p>Zitat</p></blockquote><ol><li>x</li></ol>"},
+		{"[Seite](https://example.org/ä)", `<p><a href="https://example.org/ä" rel="nofollow noopener noreferrer" target="_blank">Seite</a></p>`},
+		{"[Skript](javascript:alert(1))", "<p>[Skript](javascript:alert(1))</p>"},
+		{"Größe **über** Maß", "<p>Größe <strong>über</strong> Maß</p>"},
+	}
+
+	for _, Case := range Cases {
+
+		if HTML := string(ToHTML(Case.Source)); HTML != Case.Expected {
+			t.Errorf("ToHTML(%q):\nexpected %s\ngot      %s", Case.Source, Case.Expected, HTML)
+		}
+	}
+}
+
+func TestToPlain(t *testing.T) {
+
+	Cases := []struct {
+		Source   string
+		Expected string
+	}{
+		{"**fett** und `code`", "fett und \"code\""},
+		{"[Seite](https://example.org)", "Seite (https://example.org)"},
+		{"- a\n- b", "- a\n- b"},
+		{"> Zitat\n>\n> weiter", "> Zitat\n>\n> weiter"},
+	}
+
+	for _, Case := range Cases {
+
+		if Plain := ToPlain(Case.Source); Plain != Case.Expected {
+			t.Errorf("ToPlain(%q): expected %q, got %q", Case.Source, Case.Expected, Plain)
+		}
+	}
+}
+
+func TestToHTMLLongLine(t *testing.T) {
+
+	// Comments may be 20000 characters long. Rendering
+	// them has to take time linear in their length.
+	for _, Unit := range []string{"Wort ", "a*b ", "[x](y ", "`", "_", "**"} {
+
+		Line := strings.Repeat(Unit, (20000 / len(Unit)))
+
+		Start := time.Now()
+		ToHTML(Line)
+
+		if Elapsed := time.Since(Start); Elapsed > (250 * time.Millisecond) {
+			t.Errorf("rendering a line of %q took %s", Unit, Elapsed)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package markdown
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Variables
+
+// allowedTags are the only elements that may appear in
+// rendered comments. All of them come without attributes,
+// except for links.
+var allowedTags = map[string]bool{
+	"p":          true,
+	"br":         true,
+	"strong":     true,
+	"em":         true,
+	"code":       true,
+	"ul":         true,
+	"ol":         true,
+	"li":         true,
+	"blockquote": true,
+	"a":          true,
+}
+
+// Functions
+
+// Sanitize removes all elements and attributes from
+// supplied HTML that are not part of the supported
+// subset of Markdown. Text of removed elements is kept
+// escaped. Links may only point to web or mail addresses
+// and always open in a new tab without referrer.
+func Sanitize(Input string) string {
+
+	var Output strings.Builder
+	Tokenizer := html.NewTokenizer(strings.NewReader(Input))
+
+	// Contents of these elements are never displayed.
+	skipDepth := 0
+
+	for {
+
+		Type := Tokenizer.Next()
+		if Type == html.ErrorToken {
+			return Output.String()
+		}
+
+		Token := Tokenizer.Token()
+
+		if (Token.Data == "script") || (Token.Data == "style") {
+
+			if Type == html.StartTagToken {
+				skipDepth++
+			} else if (Type == html.EndTagToken) && (skipDepth > 0) {
+				skipDepth--
+			}
+
+			continue
+		}
+
+		if skipDepth > 0 {
+			continue
+		}
+
+		switch Type {
+
+		case html.TextToken:
+			Output.WriteString(html.EscapeString(Token.Data))
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+
+			if !allowedTags[Token.Data] {
+				continue
+			}
+
+			if Token.Data == "a" {
+
+				Href := ""
+				for _, Attr := range Token.Attr {
+
+					if (Attr.Key == "href") && allowedLink(strings.TrimSpace(Attr.Val)) {
+						Href = strings.TrimSpace(Attr.Val)
+					}
+				}
+
+				Output.WriteString("<a href=\"" + html.EscapeString(Href) + "\" rel=\"nofollow noopener noreferrer\" target=\"_blank\">")
+
+				continue
+			}
+
+			if Token.Data == "br" {
+				Output.WriteString("<br>")
+
+				continue
+			}
+
+			Output.WriteString("<" + Token.Data + ">")
+
+		case html.EndTagToken:
+
+			if allowedTags[Token.Data] && (Token.Data != "br") {
+				Output.WriteString("</" + Token.Data + ">")
+			}
+		}
+	}
+}
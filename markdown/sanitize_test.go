@@ -0,0 +1,61 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+
+	Cases := []struct {
+		Input    string
+		Expected string
+	}{
+		{`<p>Text</p>`, `<p>Text</p>`},
+		{`<a href="javascript:alert(1)">x</a>`, `<a href="" rel="nofollow noopener noreferrer" target="_blank">x</a>`},
+		{`<a href=" JaVaScRiPt:alert(1)">x</a>`, `<a href="" rel="nofollow noopener noreferrer" target="_blank">x</a>`},
+		{`<a href="data:text/html,<script>">x</a>`, `<a href="" rel="nofollow noopener noreferrer" target="_blank">x</a>`},
+		{`<a href="https://example.org" onclick="alert(1)" target="_self">x</a>`, `<a href="https://example.org" rel="nofollow noopener noreferrer" target="_blank">x</a>`},
+		{`<script>alert(1)</script>ok`, `ok`},
+		{`<style>body { display: none }</style>ok`, `ok`},
+		{`<SCRIPT>alert(1)</SCRIPT>ok`, `ok`},
+		{`<svg><script>alert(1)</script></svg>ok`, `ok`},
+		{`<img src="x" onerror="alert(1)">`, ``},
+		{`<p onmouseover="alert(1)" style="color: red">t</p>`, `<p>t</p>`},
+		{`<div><span><strong>fett</strong></span></div>`, `<strong>fett</strong>`},
+		{`<iframe src="https://example.org"><b>x</b></iframe>`, `&lt;b&gt;x&lt;/b&gt;`},
+		{`<br onclick="alert(1)"/>`, `<br>`},
+		{`a &lt;script&gt; b`, `a &lt;script&gt; b`},
+	}
+
+	for _, Case := range Cases {
+
+		if Output := Sanitize(Case.Input); Output != Case.Expected {
+			t.Errorf("Sanitize(%q):\nexpected %s\ngot      %s", Case.Input, Case.Expected, Output)
+		}
+	}
+}
+
+func TestSanitizeNeverKeepsActiveContent(t *testing.T) {
+
+	Inputs := []string{
+		`<script><script>alert(1)</script>alert(2)</script>`,
+		`<scr<script>ipt>alert(1)</script>`,
+		`<a href="javascript&#58;alert(1)">x</a>`,
+		`<object data="x"><embed src="x"></object>`,
+		`<form action="x"><input onfocus="alert(1)" autofocus></form>`,
+		`<math><style><img src=x onerror=alert(1)></style></math>`,
+	}
+
+	for _, Input := range Inputs {
+
+		Output := strings.ToLower(Sanitize(Input))
+
+		for _, Forbidden := range []string{"<script", "<img", "<object", "<embed", "<form", "<input", "javascript:", "onerror", "onfocus"} {
+
+			if strings.Contains(Output, Forbidden) {
+				t.Errorf("Sanitize(%q) kept %q: %s", Input, Forbidden, Output)
+			}
+		}
+	}
+}
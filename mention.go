@@ -0,0 +1,68 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/freitagsrunde/modulist/db"
+)
+
+// Variables
+
+// mentionPattern matches '@Vorname.Nachname' not preceded
+// by a word character, e.g. as part of a mail address.
+// Spaces within names are written as hyphens.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@])@(\p{L}[\p{L}-]*)\.(\p{L}[\p{L}-]*)`)
+
+// Functions
+
+// FindMentionedUsers returns all enabled users mentioned in
+// supplied comment who may read module descriptions. Each
+// user is returned once.
+func (app *App) FindMentionedUsers(Comment string) []db.User {
+
+	Users := []db.User{}
+	Seen := make(map[string]bool)
+
+	for _, Match := range mentionPattern.FindAllStringSubmatch(Comment, -1) {
+
+		var Candidates []db.User
+		app.DB.Preload("Roles").
+			Where("lower(replace(\"first_name\", ' ', '-')) = ? AND lower(replace(\"last_name\", ' ', '-')) = ? AND \"enabled\" = ?",
+				strings.ToLower(Match[1]), strings.ToLower(Match[2]), true).
+			Find(&Candidates)
+
+		for _, Candidate := range Candidates {
+
+			if !Seen[Candidate.ID] && Candidate.Can(db.PERMISSION_READ) {
+				Seen[Candidate.ID] = true
+				Users = append(Users, Candidate)
+			}
+		}
+	}
+
+	return Users
+}
+
+// NotifyMentions creates a notification for every user
+// mentioned in supplied feedback, except for its author.
+func (app *App) NotifyMentions(Author db.User, Feedback db.Feedback) {
+
+	for _, Mentioned := range app.FindMentionedUsers(Feedback.Comment) {
+
+		if Mentioned.ID == Author.ID {
+			continue
+		}
+
+		// Actor and module already exist, only store the references.
+		app.DB.Set("gorm:save_associations", false).Create(&db.Notification{
+			UserID:     Mentioned.ID,
+			ActorID:    Author.ID,
+			ModuleID:   Feedback.ModuleID,
+			FeedbackID: Feedback.ID,
+			Kind:       db.NOTIFICATION_MENTION,
+			Created:    time.Now(),
+		})
+	}
+}
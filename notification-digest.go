@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/freitagsrunde/modulist/db"
+)
+
+// Constants
+
+const (
+	// Subject of mails summarizing unread notifications.
+	NOTIFICATION_DIGEST_SUBJECT = "Neue Benachrichtigungen in MODULIST"
+)
+
+// Functions
+
+// ComposeNotificationDigest lists supplied notifications
+// of a user as plain text, each with a link to open it.
+func ComposeNotificationDigest(User db.User, Notifications []db.Notification, BaseURL string) string {
+
+	var Body strings.Builder
+
+	fmt.Fprintf(&Body, "Hallo %s,\n\nin MODULIST liegen ungelesene Benachrichtigungen für dich vor:\n\n", User.FirstName)
+
+	for _, Notification := range Notifications {
+
+		Module := fmt.Sprintf("Modul #%d", Notification.Module.ModuleID)
+		if Notification.Module.Title.Valid {
+			Module = fmt.Sprintf("„%s“", Notification.Module.Title.String)
+		}
+
+		fmt.Fprintf(&Body, "* %s %s hat dich im Feedback zu %s erwähnt (%s):\n  %s/notifications/open/%d\n",
+			Notification.Actor.FirstName, Notification.Actor.LastName, Module,
+			Notification.Created.Format("02.01.2006 15:04"), BaseURL, Notification.ID)
+	}
+
+	fmt.Fprintf(&Body, "\nDiese Zusammenfassung kannst du unter %s/settings abbestellen.\n", BaseURL)
+
+	return Body.String()
+}
+
+// SendNotificationDigests mails every user who asked for
+// it the unread notifications not yet mailed before.
+func (app *App) SendNotificationDigests() {
+
+	var Users []db.User
+	app.DB.Where("\"notification_digest\" = ? AND \"enabled\" = ?", true, true).Find(&Users)
+
+	for _, User := range Users {
+
+		Notifications := db.ListUnmailedNotifications(app.DB, User.ID)
+		if len(Notifications) == 0 {
+			continue
+		}
+
+		err := app.Mailer.Send([]string{User.Mail}, NOTIFICATION_DIGEST_SUBJECT, ComposeNotificationDigest(User, Notifications, app.BaseURL))
+		if err != nil {
+			log.Printf("[SendNotificationDigests] Sending digest to '%s' failed: %s.\n", User.Mail, err.Error())
+
+			continue
+		}
+
+		IDs := make([]int, len(Notifications))
+		for i, Notification := range Notifications {
+			IDs[i] = Notification.ID
+		}
+
+		app.DB.Model(&db.Notification{}).Where("\"id\" IN (?)", IDs).Update("mailed", true)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+
+	"github.com/coreos/go-oidc"
+	"golang.org/x/oauth2"
+)
+
+// Structs
+
+// OIDCLogin bundles everything needed to let users
+// log in via an external OpenID Connect provider,
+// e.g. the single sign-on service of the university.
+type OIDCLogin struct {
+	Provider      *oidc.Provider
+	Verifier      *oidc.IDTokenVerifier
+	OAuth2        oauth2.Config
+	AutoProvision bool
+}
+
+// OIDCClaims contains the claims of an ID token
+// MODULIST is interested in.
+type OIDCClaims struct {
+	Mail          string `json:"email"`
+	MailVerified  *bool  `json:"email_verified"`
+	FirstName     string `json:"given_name"`
+	LastName      string `json:"family_name"`
+	PreferredName string `json:"preferred_username"`
+}
+
+// Functions
+
+// InitOIDC reads the OpenID Connect configuration from
+// the .env file and fetches the discovery document of the
+// configured issuer. If OIDC is disabled, nil is returned.
+func InitOIDC() *OIDCLogin {
+
+	enabled, err := strconv.ParseBool(os.Getenv("OIDC_ENABLED"))
+	if err != nil {
+		log.Fatal("[InitOIDC] Unrecognized OIDC_ENABLED indicator in .env, expecting bool. Terminating.")
+	}
+
+	if !enabled {
+		return nil
+	}
+
+	autoProvision, err := strconv.ParseBool(os.Getenv("OIDC_AUTO_PROVISION"))
+	if err != nil {
+		log.Fatal("[InitOIDC] Unrecognized OIDC_AUTO_PROVISION indicator in .env, expecting bool. Terminating.")
+	}
+
+	// Retrieve the discovery document of the issuer. This works
+	// against any compliant provider, including local mock ones.
+	provider, err := oidc.NewProvider(context.Background(), os.Getenv("OIDC_ISSUER"))
+	if err != nil {
+		log.Fatalf("[InitOIDC] Could not load discovery document of OIDC issuer: %s. Terminating.", err)
+	}
+
+	clientID := os.Getenv("OIDC_CLIENT_ID")
+
+	return &OIDCLogin{
+		Provider: provider,
+		Verifier: provider.Verifier(&oidc.Config{ClientID: clientID}),
+		OAuth2: oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+			Endpoint:     provider.Endpoint(),
+			Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+		},
+		AutoProvision: autoProvision,
+	}
+}
+
+// RandomURLString returns a URL-safe string built from
+// the supplied amount of cryptographically secure bytes.
+func RandomURLString(numBytes int) (string, error) {
+
+	randomBytes := make([]byte, numBytes)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", errors.New("Could not generate random bytes.")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(randomBytes), nil
+}
+
+// PKCEChallenge derives the S256 code challenge
+// for a supplied PKCE code verifier.
+func PKCEChallenge(Verifier string) string {
+
+	sum := sha256.Sum256([]byte(Verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/freitagsrunde/modulist/db"
+	"github.com/freitagsrunde/modulist/graph"
+)
+
+// Structs
+
+// MissingRequirement is a reference in the requirements
+// of a module to a module not part of the catalogue.
+// Vanished tells whether the referenced module was part
+// of it when requirements were last extracted.
+type MissingRequirement struct {
+	Module    db.Module
+	Reference string
+	Vanished  bool
+}
+
+// RequiredModule is a module named in the
+// requirements of another module.
+type RequiredModule struct {
+	Module    db.Module
+	Mandatory bool
+}
+
+// Prerequisites holds the graph of requirements between
+// all modules together with problems found in it.
+type Prerequisites struct {
+	Graph   graph.Graph
+	Modules map[int]db.Module
+	Cycles  [][]db.Module
+	Missing []MissingRequirement
+}
+
+// Functions
+
+// moduleLabel names a module within graphs.
+func moduleLabel(Module db.Module) string {
+
+	if Module.Title.Valid {
+		return fmt.Sprintf("%s (#%d)", Module.Title.String, Module.ModuleID)
+	}
+
+	return fmt.Sprintf("Modul #%d", Module.ModuleID)
+}
+
+// BuildRequirements extracts the modules named in the
+// mandatory and optional requirements of supplied modules.
+// Numbers and titles are matched against the latest version
+// of each module. References of a module to another version
+// of itself are left out, as are optional requirements
+// already named as mandatory ones.
+func BuildRequirements(Modules []db.Module) []db.ModuleRequirement {
+
+	Latest := make(map[int]db.Module)
+	for _, Module := range Modules {
+
+		if Known, found := Latest[Module.ModuleID]; !found || (Module.Version > Known.Version) {
+			Latest[Module.ModuleID] = Module
+		}
+	}
+
+	Catalogue := make([]graph.Entry, 0, len(Latest))
+	Numbers := make(map[int]int)
+	for _, Module := range Latest {
+
+		Catalogue = append(Catalogue, graph.Entry{ID: Module.ID, Number: Module.ModuleID, Title: Module.Title.String})
+		Numbers[Module.ID] = Module.ModuleID
+	}
+
+	Requirements := []db.ModuleRequirement{}
+
+	for _, Module := range Modules {
+
+		Named := make(map[int]bool)
+
+		for _, Mandatory := range []bool{true, false} {
+
+			Text := Module.OptionalRequirements
+			if Mandatory {
+				Text = Module.MandatoryRequirements.String
+			}
+
+			for _, Reference := range graph.Extract(Text, Catalogue) {
+
+				if (Reference.ID != 0) && (Named[Reference.ID] || (Numbers[Reference.ID] == Module.ModuleID)) {
+					continue
+				}
+
+				Named[Reference.ID] = true
+				Requirements = append(Requirements, db.ModuleRequirement{
+					ModuleID:         Module.ID,
+					RequiredModuleID: Reference.ID,
+					Reference:        Reference.Text,
+					Mandatory:        Mandatory,
+				})
+			}
+		}
+	}
+
+	return Requirements
+}
+
+// RebuildRequirements extracts the requirements between
+// all modules anew and replaces the stored ones. It returns
+// how many requirements were found.
+func (app *App) RebuildRequirements() (int, error) {
+
+	var Modules []db.Module
+	app.DB.Select("\"id\", \"module_id\", \"version\", \"title\", \"mandatory_requirements\", \"optional_requirements\"").Find(&Modules)
+
+	Requirements := BuildRequirements(Modules)
+
+	return len(Requirements), db.ReplaceModuleRequirements(app.DB, Requirements)
+}
+
+// LoadPrerequisites builds the graph of all stored
+// requirements and looks for cycles and references
+// to modules not part of the catalogue.
+func (app *App) LoadPrerequisites() Prerequisites {
+
+	var Modules []db.Module
+	app.DB.Select("\"id\", \"module_id\", \"version\", \"title\"").Order("\"title\" asc").Find(&Modules)
+
+	Prerequisites := Prerequisites{
+		Modules: make(map[int]db.Module),
+		Cycles:  [][]db.Module{},
+		Missing: []MissingRequirement{},
+	}
+
+	for _, Module := range Modules {
+		Prerequisites.Modules[Module.ID] = Module
+		Prerequisites.Graph.Nodes = append(Prerequisites.Graph.Nodes, graph.Node{ID: Module.ID, Label: moduleLabel(Module)})
+	}
+
+	for _, Requirement := range db.ListModuleRequirements(app.DB) {
+
+		Module, found := Prerequisites.Modules[Requirement.ModuleID]
+		if !found {
+			continue
+		}
+
+		if _, exists := Prerequisites.Modules[Requirement.RequiredModuleID]; !exists {
+
+			Prerequisites.Missing = append(Prerequisites.Missing, MissingRequirement{
+				Module:    Module,
+				Reference: Requirement.Reference,
+				Vanished:  Requirement.RequiredModuleID != 0,
+			})
+
+			continue
+		}
+
+		Prerequisites.Graph.Edges = append(Prerequisites.Graph.Edges, graph.Edge{
+			From:      Requirement.RequiredModuleID,
+			To:        Requirement.ModuleID,
+			Mandatory: Requirement.Mandatory,
+		})
+	}
+
+	for _, Cycle := range Prerequisites.Graph.Cycles() {
+
+		Members := make([]db.Module, len(Cycle))
+		for i, ID := range Cycle {
+			Members[i] = Prerequisites.Modules[ID]
+		}
+
+		Prerequisites.Cycles = append(Prerequisites.Cycles, Members)
+	}
+
+	return Prerequisites
+}
+
+// LoadRequiredModules loads the modules named in the stored
+// requirements of the module with supplied ID. References to
+// modules not part of the catalogue are returned separately.
+func (app *App) LoadRequiredModules(ModuleID int) ([]RequiredModule, []string) {
+
+	var Requirements []db.ModuleRequirement
+	app.DB.Where("\"module_id\" = ?", ModuleID).Order("\"id\" asc").Find(&Requirements)
+
+	Required := []RequiredModule{}
+	Missing := []string{}
+
+	for _, Requirement := range Requirements {
+
+		var Module db.Module
+		if Requirement.RequiredModuleID != 0 {
+			app.DB.Select("\"id\", \"module_id\", \"version\", \"title\"").First(&Module, "\"id\" = ?", Requirement.RequiredModuleID)
+		}
+
+		if Module.ID == 0 {
+			Missing = append(Missing, Requirement.Reference)
+
+			continue
+		}
+
+		Required = append(Required, RequiredModule{Module: Module, Mandatory: Requirement.Mandatory})
+	}
+
+	return Required, Missing
+}
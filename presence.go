@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/freitagsrunde/modulist/db"
+)
+
+// Constants
+
+const (
+	// How long a user counts as present on a module after
+	// the last heartbeat. Pages send one every 30 seconds.
+	PRESENCE_EXPIRY = 90 * time.Second
+)
+
+// Structs
+
+// Presence tells that a user currently has a module open.
+type Presence struct {
+	UserID  string
+	Name    string
+	Expires time.Time `json:"-"`
+}
+
+// PresenceRegistry keeps track of who has which module
+// open, based on heartbeats of module pages. It lives in
+// memory only and starts out empty after a restart.
+type PresenceRegistry struct {
+	lock    sync.Mutex
+	modules map[int]map[string]Presence
+}
+
+// Functions
+
+// NewPresenceRegistry returns a registry without anyone present.
+func NewPresenceRegistry() *PresenceRegistry {
+
+	return &PresenceRegistry{
+		modules: make(map[int]map[string]Presence),
+	}
+}
+
+// Heartbeat marks supplied user as present on the
+// module with supplied ID for another expiry period.
+func (registry *PresenceRegistry) Heartbeat(ModuleID int, User db.User) {
+
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	if registry.modules[ModuleID] == nil {
+		registry.modules[ModuleID] = make(map[string]Presence)
+	}
+
+	registry.modules[ModuleID][User.ID] = Presence{
+		UserID:  User.ID,
+		Name:    fmt.Sprintf("%s %s", User.FirstName, User.LastName),
+		Expires: time.Now().Add(PRESENCE_EXPIRY),
+	}
+}
+
+// Leave removes supplied user from the module
+// with supplied ID, e.g. on closing the page.
+func (registry *PresenceRegistry) Leave(ModuleID int, UserID string) {
+
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	delete(registry.modules[ModuleID], UserID)
+	if len(registry.modules[ModuleID]) == 0 {
+		delete(registry.modules, ModuleID)
+	}
+}
+
+// prune removes all expired presences. The
+// lock has to be held by the caller.
+func (registry *PresenceRegistry) prune() {
+
+	Now := time.Now()
+
+	for ModuleID, Users := range registry.modules {
+
+		for UserID, Presence := range Users {
+
+			if Now.After(Presence.Expires) {
+				delete(Users, UserID)
+			}
+		}
+
+		if len(Users) == 0 {
+			delete(registry.modules, ModuleID)
+		}
+	}
+}
+
+// sortedPresences returns supplied presences ordered by
+// name, leaving out the user with supplied ID.
+func sortedPresences(Users map[string]Presence, ExceptUserID string) []Presence {
+
+	Presences := []Presence{}
+	for UserID, Presence := range Users {
+
+		if UserID != ExceptUserID {
+			Presences = append(Presences, Presence)
+		}
+	}
+
+	sort.Slice(Presences, func(i, j int) bool {
+		return Presences[i].Name < Presences[j].Name
+	})
+
+	return Presences
+}
+
+// Present returns everyone but the user with supplied
+// ID who currently has the module with supplied ID open.
+func (registry *PresenceRegistry) Present(ModuleID int, ExceptUserID string) []Presence {
+
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	registry.prune()
+
+	return sortedPresences(registry.modules[ModuleID], ExceptUserID)
+}
+
+// All returns for each module someone but the user with
+// supplied ID currently has open who that is.
+func (registry *PresenceRegistry) All(ExceptUserID string) map[int][]Presence {
+
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	registry.prune()
+
+	Modules := make(map[int][]Presence)
+	for ModuleID, Users := range registry.modules {
+
+		if Presences := sortedPresences(Users, ExceptUserID); len(Presences) > 0 {
+			Modules[ModuleID] = Presences
+		}
+	}
+
+	return Modules
+}
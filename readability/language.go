@@ -0,0 +1,127 @@
+package readability
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Constants
+
+const (
+	// Languages texts are detected to be written in.
+	// Empty if the language could not be determined.
+	GERMAN  = "de"
+	ENGLISH = "en"
+
+	// Stop words needed to determine the language, and the
+	// share of them that has to belong to one language.
+	MIN_STOP_WORDS   = 3
+	STOP_WORD_MARGIN = 2.0 / 3.0
+)
+
+// Variables
+
+var (
+	// Frequent words only occurring in one of the languages.
+	germanStopWords = toSet("der", "die", "das", "und", "ist", "sind", "nicht", "mit", "von", "zu", "den", "des",
+		"im", "ein", "eine", "einer", "eines", "einem", "für", "auf", "werden", "wird", "sich", "dem", "als",
+		"auch", "sie", "können", "kann", "über", "bei", "nach", "durch", "oder", "wie", "zur", "zum", "aus", "sowie")
+	englishStopWords = toSet("the", "and", "of", "to", "is", "are", "for", "with", "on", "as", "be", "by", "this",
+		"that", "from", "or", "can", "will", "students", "their", "these", "which", "it", "at", "how", "such")
+
+	// Forms of the auxiliary verbs of the passive voice.
+	germanPassiveAuxiliaries  = toSet("wird", "werden", "wurde", "wurden", "worden", "werde", "wirst", "würde", "würden")
+	englishPassiveAuxiliaries = toSet("is", "are", "was", "were", "be", "been", "being", "am")
+
+	// germanParticiple matches past participles, either formed
+	// with 'ge', possibly after a separable prefix, with an
+	// inseparable prefix or ending in '-iert'.
+	germanParticiple = regexp.MustCompile(`^(\p{L}{0,6}ge\p{L}{2,}(t|en)|(be|ver|er|ent|zer|über|unter|hinter|miss)\p{L}{2,}t|\p{L}{2,}iert)$`)
+
+	// englishParticiples are frequent irregular past participles.
+	englishParticiples = toSet("taught", "given", "done", "written", "shown", "known", "made", "seen", "held",
+		"built", "chosen", "taken", "put", "set", "read", "found", "brought", "thought", "understood", "led")
+)
+
+// Functions
+
+// toSet builds a lookup table of supplied words.
+func toSet(Words ...string) map[string]bool {
+
+	Set := make(map[string]bool)
+	for _, Word := range Words {
+		Set[Word] = true
+	}
+
+	return Set
+}
+
+// DetectLanguage tells whether supplied text is written
+// in German or English by counting stop words of both.
+// Texts without enough of them are left undetermined.
+func DetectLanguage(Text string) string {
+
+	German, English := 0, 0
+	for _, Word := range Words(strings.ToLower(Text)) {
+
+		if germanStopWords[Word] {
+			German++
+		} else if englishStopWords[Word] {
+			English++
+		}
+	}
+
+	Total := German + English
+	if Total < MIN_STOP_WORDS {
+		return ""
+	}
+
+	switch {
+	case float64(German) >= (STOP_WORD_MARGIN * float64(Total)):
+		return GERMAN
+	case float64(English) >= (STOP_WORD_MARGIN * float64(Total)):
+		return ENGLISH
+	}
+
+	return ""
+}
+
+// IsPassive reports whether supplied sentence is written
+// in passive voice: an auxiliary like 'wird' or 'is'
+// accompanied by a past participle. In English, the
+// participle has to follow within three words.
+func IsPassive(Sentence string, Language string) bool {
+
+	Words := Words(strings.ToLower(Sentence))
+
+	if Language == ENGLISH {
+
+		for i, Word := range Words {
+
+			if !englishPassiveAuxiliaries[Word] {
+				continue
+			}
+
+			for j := (i + 1); (j < len(Words)) && (j <= (i + 3)); j++ {
+
+				if englishParticiples[Words[j]] || ((len(Words[j]) > 4) && strings.HasSuffix(Words[j], "ed")) {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+
+	Auxiliary, Participle := false, false
+	for _, Word := range Words {
+
+		if germanPassiveAuxiliaries[Word] {
+			Auxiliary = true
+		} else if germanParticiple.MatchString(Word) {
+			Participle = true
+		}
+	}
+
+	return Auxiliary && Participle
+}
@@ -0,0 +1,224 @@
+package readability
+
+import (
+	"math"
+	"strings"
+
+	"github.com/freitagsrunde/modulist/db"
+)
+
+// Constants
+
+const (
+	// Texts with fewer words are not scored, as the
+	// formulas are meaningless for a few words.
+	MIN_WORDS = 20
+)
+
+// Variables
+
+// Fields are the free text fields of a module whose
+// readability is scored. Literature is left out, as
+// lists of references are no prose.
+var Fields = []string{
+	"LearningOutcomes", "LearningOutcomesEnglish",
+	"TeachingContents", "TeachingContentsEnglish",
+	"InstructiveForm", "OptionalRequirements", "MandatoryRequirements",
+	"ExaminationDescription", "RegistrationFormalities", "Miscellaneous",
+}
+
+// Structs
+
+// Metrics describe how hard a text is to read. Score is the
+// Flesch Reading Ease for English texts and its adaptation
+// by Amstad for German ones, both limited to 0 (very hard)
+// to 100 (very easy).
+type Metrics struct {
+	Words          int
+	Sentences      int
+	SentenceLength float64
+	Score          float64
+	PassiveRatio   float64
+}
+
+// FieldReport holds the metrics of one field of a module.
+// Language is the detected language, Expected the one the
+// field should be written in, empty if both are fine.
+type FieldReport struct {
+	Field    string
+	Title    string
+	Language string
+	Expected string
+	Mismatch bool
+	Metrics
+}
+
+// Report holds the metrics of all scored fields of a module.
+// Score is the average of their scores, weighted by words.
+type Report struct {
+	Fields     []FieldReport
+	Words      int
+	Score      float64
+	Mismatches int
+}
+
+// Functions
+
+// Measure computes the metrics of supplied text,
+// using the formula of supplied language.
+func Measure(Text string, Language string) Metrics {
+
+	Metrics := Metrics{}
+	SyllableCount := 0
+	Passive := 0
+
+	for _, Sentence := range Sentences(Text) {
+
+		Metrics.Sentences++
+
+		for _, Word := range Words(Sentence) {
+			Metrics.Words++
+			SyllableCount += Syllables(Word, Language)
+		}
+
+		if IsPassive(Sentence, Language) {
+			Passive++
+		}
+	}
+
+	if (Metrics.Words == 0) || (Metrics.Sentences == 0) {
+		return Metrics
+	}
+
+	Metrics.SentenceLength = float64(Metrics.Words) / float64(Metrics.Sentences)
+	SyllablesPerWord := float64(SyllableCount) / float64(Metrics.Words)
+
+	if Language == ENGLISH {
+		Metrics.Score = 206.835 - (1.015 * Metrics.SentenceLength) - (84.6 * SyllablesPerWord)
+	} else {
+		Metrics.Score = 180 - Metrics.SentenceLength - (58.5 * SyllablesPerWord)
+	}
+
+	Metrics.Score = math.Max(0, math.Min(100, Metrics.Score))
+	Metrics.PassiveRatio = float64(Passive) / float64(Metrics.Sentences)
+
+	return Metrics
+}
+
+// expectedLanguage returns the language supplied field of a
+// module should be written in. English fields are expected
+// in English, all others in the language the module is
+// taught in. Modules taught in both allow either.
+func expectedLanguage(Field string, Lang string) string {
+
+	if strings.HasSuffix(Field, "English") {
+		return ENGLISH
+	}
+
+	switch Lang {
+	case "GER":
+		return GERMAN
+	case "ENG":
+		return ENGLISH
+	}
+
+	return ""
+}
+
+// Analyze scores all fields of supplied module long
+// enough to be scored and checks their language.
+func Analyze(Module db.Module) Report {
+
+	Titles := db.FieldTitles()
+	Report := Report{Fields: []FieldReport{}}
+	Total := 0.0
+
+	for _, Field := range Fields {
+
+		Text, _ := Module.AnchorText(Field)
+		if len(Words(Text)) < MIN_WORDS {
+			continue
+		}
+
+		FieldReport := FieldReport{
+			Field:    Field,
+			Title:    Titles[Field],
+			Language: DetectLanguage(Text),
+			Expected: expectedLanguage(Field, Module.Lang),
+		}
+
+		// Score undetermined texts as expected, German by default.
+		Language := FieldReport.Language
+		if Language == "" {
+			Language = FieldReport.Expected
+		}
+
+		FieldReport.Metrics = Measure(Text, Language)
+		FieldReport.Mismatch = (FieldReport.Language != "") && (FieldReport.Expected != "") && (FieldReport.Language != FieldReport.Expected)
+
+		if FieldReport.Mismatch {
+			Report.Mismatches++
+		}
+
+		Report.Fields = append(Report.Fields, FieldReport)
+		Report.Words += FieldReport.Words
+		Total += FieldReport.Score * float64(FieldReport.Words)
+	}
+
+	if Report.Words > 0 {
+		Report.Score = Total / float64(Report.Words)
+	}
+
+	return Report
+}
+
+// Level describes supplied score in words,
+// following the usual Flesch scale.
+func Level(Score float64) string {
+
+	switch {
+	case Score >= 90:
+		return "sehr leicht"
+	case Score >= 80:
+		return "leicht"
+	case Score >= 70:
+		return "eher leicht"
+	case Score >= 60:
+		return "mittel"
+	case Score >= 50:
+		return "eher schwer"
+	case Score >= 30:
+		return "schwer"
+	}
+
+	return "sehr schwer"
+}
+
+// Level describes the score of these metrics in words.
+func (metrics Metrics) Level() string {
+	return Level(metrics.Score)
+}
+
+// PassivePercent returns the share of sentences
+// in passive voice in percent.
+func (metrics Metrics) PassivePercent() int {
+	return int(math.Round(metrics.PassiveRatio * 100))
+}
+
+// Level describes the average score of a module in words.
+func (report Report) Level() string {
+	return Level(report.Score)
+}
+
+// LanguageTitles returns the names of all
+// detected languages as displayed to users.
+func LanguageTitles() map[string]string {
+
+	Titles := make(map[string]string)
+
+	Titles[GERMAN] = "Deutsch"
+	Titles[ENGLISH] = "Englisch"
+	Titles[""] = "unbestimmt"
+
+	return Titles
+}
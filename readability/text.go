@@ -0,0 +1,145 @@
+package readability
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Variables
+
+var (
+	// listMarker matches bullets and enumerations
+	// at the start of a line.
+	listMarker = regexp.MustCompile(`^\s*([-*•–]|[0-9]{1,2}[.)]|[a-z][)])\s+`)
+
+	// abbreviations end in a period that does not end
+	// the sentence. Single letters are always taken to
+	// be abbreviations, e.g. in 'z. B.' or 'e.g.'.
+	abbreviations = map[string]bool{
+		"bzw": true, "ca": true, "usw": true, "vgl": true, "evtl": true, "ggf": true,
+		"inkl": true, "bspw": true, "etc": true, "dr": true, "prof": true, "nr": true,
+		"sog": true, "max": true, "min": true, "std": true, "vs": true, "al": true,
+		"engl": true, "dt": true, "ff": true, "bzgl": true, "insb": true, "resp": true,
+	}
+)
+
+// Functions
+
+// Words splits supplied text into words. Numbers and other
+// tokens without any letter are not counted as words.
+func Words(Text string) []string {
+
+	Words := []string{}
+
+	for _, Token := range strings.FieldsFunc(Text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+
+		if strings.IndexFunc(Token, unicode.IsLetter) >= 0 {
+			Words = append(Words, Token)
+		}
+	}
+
+	return Words
+}
+
+// endsSentence reports whether the punctuation at supplied
+// position of the line ends a sentence.
+func endsSentence(Line []rune, Position int) bool {
+
+	// The sentence only ends before whitespace.
+	if ((Position + 1) < len(Line)) && !unicode.IsSpace(Line[Position+1]) {
+		return false
+	}
+
+	if Line[Position] != '.' {
+		return true
+	}
+
+	Start := Position
+	for (Start > 0) && unicode.IsLetter(Line[Start-1]) {
+		Start--
+	}
+
+	Word := strings.ToLower(string(Line[Start:Position]))
+
+	return (len([]rune(Word)) > 1) && !abbreviations[Word]
+}
+
+// Sentences splits supplied text into sentences. Line breaks
+// end sentences as well, as lists are often written without
+// punctuation. List markers are removed.
+func Sentences(Text string) []string {
+
+	Sentences := []string{}
+
+	add := func(Sentence []rune) {
+
+		if len(Words(string(Sentence))) > 0 {
+			Sentences = append(Sentences, strings.TrimSpace(string(Sentence)))
+		}
+	}
+
+	for _, Line := range strings.Split(strings.Replace(Text, "\r", "\n", -1), "\n") {
+
+		Runes := []rune(listMarker.ReplaceAllString(Line, ""))
+
+		Start := 0
+		for i, r := range Runes {
+
+			if strings.ContainsRune(".!?", r) && endsSentence(Runes, i) {
+				add(Runes[Start:(i + 1)])
+				Start = i + 1
+			}
+		}
+
+		add(Runes[Start:])
+	}
+
+	return Sentences
+}
+
+// Syllables estimates the syllables of supplied word by
+// counting groups of vowels. Diphthongs like 'ei' and 'au'
+// form one group. In English, a silent final 'e' and the
+// ending 'ed' after most consonants are not counted.
+func Syllables(Word string, Language string) int {
+
+	Runes := []rune(strings.ToLower(Word))
+
+	Vowels := "aeiouyäöü"
+	if Language == ENGLISH {
+		Vowels = "aeiouy"
+	}
+
+	Count := 0
+	Previous := false
+	for _, r := range Runes {
+
+		Vowel := strings.ContainsRune(Vowels, r)
+		if Vowel && !Previous {
+			Count++
+		}
+
+		Previous = Vowel
+	}
+
+	if (Language == ENGLISH) && (Count > 1) && (len(Runes) > 2) {
+
+		Last := Runes[len(Runes)-1]
+		Before := Runes[len(Runes)-2]
+
+		if (Last == 'e') && (Before != 'l') && !strings.ContainsRune(Vowels, Before) {
+			Count--
+		} else if (Last == 'd') && (Before == 'e') && !strings.ContainsRune("aeiouytd", Runes[len(Runes)-3]) {
+			Count--
+		}
+	}
+
+	if Count < 1 {
+		Count = 1
+	}
+
+	return Count
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"crypto/rand"
@@ -18,23 +19,57 @@ import (
 // Structs
 
 type CreateUserPayload struct {
-	FirstName   string `form:"user-first-name" conform:"trim" validate:"required,excludesall=!@#$%^&*()_+-=:;?/0x2C0x7C"`
-	LastName    string `form:"user-last-name" conform:"trim" validate:"required,excludesall=!@#$%^&*()_+-=:;?/0x2C0x7C"`
-	Mail        string `form:"user-mail" conform:"trim,email" validate:"required,email"`
-	StatusGroup int    `form:"user-status-group" conform:"trim" validate:"min=0"`
-	Privileges  int    `form:"user-privileges" conform:"trim" validate:"min=0"`
+	FirstName   string   `form:"user-first-name" conform:"trim" validate:"required,excludesall=!@#$%^&*()_+-=:;?/0x2C0x7C"`
+	LastName    string   `form:"user-last-name" conform:"trim" validate:"required,excludesall=!@#$%^&*()_+-=:;?/0x2C0x7C"`
+	Mail        string   `form:"user-mail" conform:"trim,email" validate:"required,email"`
+	StatusGroup int      `form:"user-status-group" conform:"trim" validate:"min=0"`
+	Roles       []string `form:"user-roles" validate:"required,min=1"`
+	Office      string   `form:"user-office" conform:"trim"`
+}
+
+type UpdateUserRolesPayload struct {
+	Roles []string `form:"user-roles"`
 }
 
 type ActDeactUserPayload struct {
 	ID string `conform:"trim" validate:"required,uuid4"`
 }
 
+type FeedbackMailsPayload struct {
+	FeedbackFilterPayload
+	Consensus bool `form:"consensus"`
+}
+
+type SendFeedbackMailPayload struct {
+	FeedbackMailsPayload
+	Mail string `form:"mail" conform:"trim,email" validate:"required,email"`
+}
+
+type UpdateMailTemplatePayload struct {
+	Name    string `conform:"trim" validate:"required,eq=mail-header|eq=mail-footer"`
+	Content string `form:"content"`
+}
+
 // Functions
 
+// MailFilter returns the filter selecting the comments
+// to mail. Unless statuses were chosen, only open and
+// accepted comments are sent.
+func (payload FeedbackMailsPayload) MailFilter() db.FeedbackFilter {
+
+	Filter := payload.Filter()
+
+	if len(Filter.Statuses) == 0 {
+		Filter.Statuses = []string{db.STATUS_OPEN, db.STATUS_ACCEPTED}
+	}
+
+	return Filter
+}
+
 func (app *App) ListUsers(c *gin.Context) {
 
 	// Check if user is authorized.
-	User, err := app.Authorize(c.Request, db.PRIVILEGE_ADMIN)
+	User, err := app.Authorize(c.Request, db.PERMISSION_MANAGE_USERS)
 	if err != nil {
 		c.Redirect(http.StatusFound, "/modules")
 
@@ -46,19 +81,20 @@ func (app *App) ListUsers(c *gin.Context) {
 
 	// Fetch all users registered in database.
 	var Users []db.User
-	app.DB.Find(&Users)
+	app.DB.Preload("Roles").Find(&Users)
 
-	c.HTML(http.StatusOK, "admin-users.html", gin.H{
+	app.RenderHTML(c, http.StatusOK, "admin-users.html", gin.H{
 		"PageTitle": "Admin - Nutzerverwaltung",
 		"User":      User,
 		"Users":     Users,
+		"Roles":     db.ListRoles(app.DB),
 	})
 }
 
 func (app *App) CreateUser(c *gin.Context) {
 
 	// Check if user is authorized.
-	User, err := app.Authorize(c.Request, db.PRIVILEGE_ADMIN)
+	User, err := app.Authorize(c.Request, db.PERMISSION_MANAGE_USERS)
 	if err != nil {
 		c.Redirect(http.StatusFound, "/modules")
 
@@ -74,12 +110,13 @@ func (app *App) CreateUser(c *gin.Context) {
 	err = c.BindWith(&Payload, binding.FormPost)
 	if err != nil {
 
-		app.DB.Find(&Users)
+		app.DB.Preload("Roles").Find(&Users)
 
-		c.HTML(http.StatusBadRequest, "admin-users.html", gin.H{
+		app.RenderHTML(c, http.StatusBadRequest, "admin-users.html", gin.H{
 			"PageTitle":  "Admin - Nutzerverwaltung",
 			"User":       User,
 			"Users":      Users,
+			"Roles":      db.ListRoles(app.DB),
 			"FatalError": "Gesendete Daten für neuen Nutzer konnten nicht verarbeitet werden. Bitte erneut versuchen.",
 		})
 
@@ -90,13 +127,14 @@ func (app *App) CreateUser(c *gin.Context) {
 	ErrorDesc := app.ConformAndValidate(&Payload)
 	if ErrorDesc != nil {
 
-		app.DB.Find(&Users)
+		app.DB.Preload("Roles").Find(&Users)
 
 		// If payload did not pass, report errors to user.
-		c.HTML(http.StatusBadRequest, "admin-users.html", gin.H{
+		app.RenderHTML(c, http.StatusBadRequest, "admin-users.html", gin.H{
 			"PageTitle": "Admin - Nutzerverwaltung",
 			"User":      User,
 			"Users":     Users,
+			"Roles":     db.ListRoles(app.DB),
 			"Errors":    ErrorDesc,
 		})
 
@@ -112,9 +150,27 @@ func (app *App) CreateUser(c *gin.Context) {
 	NewUser.Mail = Payload.Mail
 	NewUser.MailVerified = false
 	NewUser.StatusGroup = Payload.StatusGroup
-	NewUser.Privileges = Payload.Privileges
+	NewUser.Privileges = db.PRIVILEGE_MIGRATED
+	NewUser.Roles = db.FindRoles(app.DB, Payload.Roles)
+	NewUser.Office = Payload.Office
 	NewUser.Enabled = false
 
+	// All requested roles have to exist.
+	if len(NewUser.Roles) != len(Payload.Roles) {
+
+		app.DB.Preload("Roles").Find(&Users)
+
+		app.RenderHTML(c, http.StatusBadRequest, "admin-users.html", gin.H{
+			"PageTitle":  "Admin - Nutzerverwaltung",
+			"User":       User,
+			"Users":      Users,
+			"Roles":      db.ListRoles(app.DB),
+			"FatalError": "Mindestens eine der gewählten Rollen existiert nicht.",
+		})
+
+		return
+	}
+
 	// Generate a random, secure password hash for new user.
 	// Will never be used but we have to satisfy the database
 	// constraints and also have some worst case help.
@@ -124,13 +180,14 @@ func (app *App) CreateUser(c *gin.Context) {
 
 		log.Printf("[CreateUser] Generating random bytes for temporary user password went wrong: %s.\n", err.Error())
 
-		app.DB.Find(&Users)
+		app.DB.Preload("Roles").Find(&Users)
 
 		// Report fatal error to user.
-		c.HTML(http.StatusInternalServerError, "admin-users.html", gin.H{
+		app.RenderHTML(c, http.StatusInternalServerError, "admin-users.html", gin.H{
 			"PageTitle":  "Admin - Nutzerverwaltung",
 			"User":       User,
 			"Users":      Users,
+			"Roles":      db.ListRoles(app.DB),
 			"FatalError": "Auf dem Server ist ein Fehler aufgetreten. Erneut versuchen oder Admin kontaktieren.",
 		})
 
@@ -145,13 +202,14 @@ func (app *App) CreateUser(c *gin.Context) {
 
 		log.Printf("[CreateUser] Creating bcrypt password hash went wrong: %s.\n", err.Error())
 
-		app.DB.Find(&Users)
+		app.DB.Preload("Roles").Find(&Users)
 
 		// Report fatal error to user.
-		c.HTML(http.StatusInternalServerError, "admin-users.html", gin.H{
+		app.RenderHTML(c, http.StatusInternalServerError, "admin-users.html", gin.H{
 			"PageTitle":  "Admin - Nutzerverwaltung",
 			"User":       User,
 			"Users":      Users,
+			"Roles":      db.ListRoles(app.DB),
 			"FatalError": "Auf dem Server ist ein Fehler aufgetreten. Erneut versuchen oder Admin kontaktieren.",
 		})
 
@@ -177,13 +235,14 @@ func (app *App) CreateUser(c *gin.Context) {
 
 		log.Printf("[CreateUser] Generating random bytes for password link went wrong: %s.\n", err.Error())
 
-		app.DB.Find(&Users)
+		app.DB.Preload("Roles").Find(&Users)
 
 		// Report fatal error to user.
-		c.HTML(http.StatusInternalServerError, "admin-users.html", gin.H{
+		app.RenderHTML(c, http.StatusInternalServerError, "admin-users.html", gin.H{
 			"PageTitle":  "Admin - Nutzerverwaltung",
 			"User":       User,
 			"Users":      Users,
+			"Roles":      db.ListRoles(app.DB),
 			"FatalError": "Auf dem Server ist ein Fehler aufgetreten. Erneut versuchen oder Admin kontaktieren.",
 		})
 
@@ -203,12 +262,13 @@ func (app *App) CreateUser(c *gin.Context) {
 	app.DB.Create(&NewUser)
 
 	// Retrieve an updated list of all users to display.
-	app.DB.Find(&Users)
+	app.DB.Preload("Roles").Find(&Users)
 
-	c.HTML(http.StatusOK, "admin-users.html", gin.H{
+	app.RenderHTML(c, http.StatusOK, "admin-users.html", gin.H{
 		"PageTitle": "Admin - Nutzerverwaltung",
 		"User":      User,
 		"Users":     Users,
+		"Roles":     db.ListRoles(app.DB),
 		"Success":   "Nutzer angelegt! Eine Mail mit einem Link zum Setzen des Passworts wurde versandt.",
 	})
 }
@@ -220,7 +280,7 @@ func (app *App) CreateUser(c *gin.Context) {
 func (app *App) DeactivateUser(c *gin.Context) {
 
 	// Check if user is authorized.
-	User, err := app.Authorize(c.Request, db.PRIVILEGE_ADMIN)
+	User, err := app.Authorize(c.Request, db.PERMISSION_MANAGE_USERS)
 	if err != nil {
 		c.Redirect(http.StatusFound, "/modules")
 
@@ -259,7 +319,7 @@ func (app *App) DeactivateUser(c *gin.Context) {
 func (app *App) ActivateUser(c *gin.Context) {
 
 	// Check if user is authorized.
-	User, err := app.Authorize(c.Request, db.PRIVILEGE_ADMIN)
+	User, err := app.Authorize(c.Request, db.PERMISSION_MANAGE_USERS)
 	if err != nil {
 		c.Redirect(http.StatusFound, "/modules")
 
@@ -346,10 +406,96 @@ func (app *App) ActivateUser(c *gin.Context) {
 	c.Redirect(http.StatusFound, "/admin/users")
 }
 
+// UpdateUserRoles replaces the roles of the user
+// specified by ID with the roles selected in the
+// form of the admin's users site.
+func (app *App) UpdateUserRoles(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_MANAGE_USERS)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/modules")
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	// Retrieve ID of user to update from URL.
+	IDPayload := ActDeactUserPayload{
+		ID: c.Param("id"),
+	}
+
+	// Check if sent ID is conform and valid.
+	if errs := app.ConformAndValidate(&IDPayload); errs != nil {
+		c.Redirect(http.StatusFound, "/admin/users")
+
+		return
+	}
+
+	var Payload UpdateUserRolesPayload
+
+	err = c.BindWith(&Payload, binding.FormPost)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/admin/users")
+
+		return
+	}
+
+	// Load concerned user and requested roles from database.
+	var ChangedUser db.User
+	app.DB.First(&ChangedUser, "\"id\" = ?", IDPayload.ID)
+
+	Roles := db.FindRoles(app.DB, Payload.Roles)
+
+	var Users []db.User
+
+	if (ChangedUser.ID == "") || (len(Roles) != len(Payload.Roles)) {
+
+		app.DB.Preload("Roles").Find(&Users)
+
+		app.RenderHTML(c, http.StatusBadRequest, "admin-users.html", gin.H{
+			"PageTitle":  "Admin - Nutzerverwaltung",
+			"User":       User,
+			"Users":      Users,
+			"Roles":      db.ListRoles(app.DB),
+			"FatalError": "Nutzer oder gewählte Rollen existieren nicht.",
+		})
+
+		return
+	}
+
+	// Prevent admins from locking themselves out of user management.
+	ChangedUser.Roles = Roles
+	if (ChangedUser.ID == User.ID) && !ChangedUser.Can(db.PERMISSION_MANAGE_USERS) {
+
+		app.DB.Preload("Roles").Find(&Users)
+
+		app.RenderHTML(c, http.StatusBadRequest, "admin-users.html", gin.H{
+			"PageTitle":  "Admin - Nutzerverwaltung",
+			"User":       User,
+			"Users":      Users,
+			"Roles":      db.ListRoles(app.DB),
+			"FatalError": "Die eigenen Rollen müssen weiterhin die Nutzerverwaltung erlauben.",
+		})
+
+		return
+	}
+
+	// Save new set of roles.
+	app.DB.Model(&ChangedUser).Association("Roles").Replace(Roles)
+
+	// Redirect if everything was successful.
+	c.Redirect(http.StatusFound, "/admin/users")
+}
+
+// SendFeedback shows the mails aggregating all feedback
+// per module owner, ready to be sent out one by one.
 func (app *App) SendFeedback(c *gin.Context) {
 
 	// Check if user is authorized.
-	User, err := app.Authorize(c.Request, db.PRIVILEGE_ADMIN)
+	User, err := app.Authorize(c.Request, db.PERMISSION_SEND_FEEDBACK)
 	if err != nil {
 		c.Redirect(http.StatusFound, "/modules")
 
@@ -359,15 +505,181 @@ func (app *App) SendFeedback(c *gin.Context) {
 	// Update expiration time of session.
 	app.CreateSession(c, *User)
 
-	// TODO: Correct admin send feedback page behaviour.
+	// Read which comments to include and whether to append
+	// the consensus of resolved discussions.
+	var Payload FeedbackMailsPayload
+
+	err = c.BindWith(&Payload, binding.Form)
+	if err == nil {
 
-	c.HTML(http.StatusOK, "admin-send-feedback.html", gin.H{
-		"PageTitle":     "Admin - Feedback versenden",
-		"User":          User,
-		"FeedbackMails": struct{}{},
-		"MailHeader":    "A",
-		"MailFooter":    "B",
+		if errs := app.ConformAndValidate(&Payload); errs != nil {
+			Payload = FeedbackMailsPayload{}
+		}
+	}
+
+	Filter := Payload.MailFilter()
+
+	FeedbackMails, WithoutMail := app.LoadFeedbackMails(Payload.Consensus, Filter)
+
+	// Mark chosen options of the filter form.
+	SelectedSeverities := make(map[string]bool)
+	for _, Severity := range Filter.Severities {
+		SelectedSeverities[Severity] = true
+	}
+
+	SelectedStatuses := make(map[string]bool)
+	for _, Status := range Filter.Statuses {
+		SelectedStatuses[Status] = true
+	}
+
+	app.RenderHTML(c, http.StatusOK, "admin-send-feedback.html", gin.H{
+		"PageTitle":          "Admin - Feedback versenden",
+		"User":               User,
+		"FeedbackMails":      FeedbackMails,
+		"WithoutMail":        WithoutMail,
+		"IncludeConsensus":   Payload.Consensus,
+		"Filter":             Filter,
+		"FilterQuery":        c.Request.URL.RawQuery,
+		"Severities":         db.Severities(),
+		"SeverityTitles":     db.SeverityTitles(),
+		"SelectedSeverities": SelectedSeverities,
+		"Statuses":           db.Statuses(),
+		"StatusTitles":       db.StatusTitles(),
+		"SelectedStatuses":   SelectedStatuses,
+		"MailHeader":         db.LoadMailTemplate(app.DB, db.MAIL_TEMPLATE_HEADER),
+		"MailFooter":         db.LoadMailTemplate(app.DB, db.MAIL_TEMPLATE_FOOTER),
+	})
+}
+
+// SendFeedbackMail sends the aggregated feedback to one
+// module owner. The mail is composed again on the server,
+// thus only the recipient has to be supplied.
+func (app *App) SendFeedbackMail(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_SEND_FEEDBACK)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"Reason": "Not authorized.",
+		})
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	var Payload SendFeedbackMailPayload
+
+	err = c.BindWith(&Payload, binding.FormPost)
+	if err != nil {
+
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Reason": "Internal error. Please try again later.",
+		})
+
+		return
+	}
+
+	// Check sent content for validity.
+	ErrorDesc := app.ConformAndValidate(&Payload)
+	if ErrorDesc != nil {
+
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Reason":            "Malformed input. Please check your values for validity and try again.",
+			"ErrorDescriptions": ErrorDesc,
+		})
+
+		return
+	}
+
+	FeedbackMails, _ := app.LoadFeedbackMails(Payload.Consensus, Payload.MailFilter())
+
+	for _, FeedbackMail := range FeedbackMails {
+
+		if FeedbackMail.Mail != strings.ToLower(Payload.Mail) {
+			continue
+		}
+
+		if err := app.Mailer.Send([]string{FeedbackMail.Mail}, FEEDBACK_MAIL_SUBJECT, FeedbackMail.Text); err != nil {
+
+			log.Printf("[SendFeedbackMail] Sending feedback to '%s' failed: %s.\n", FeedbackMail.Mail, err.Error())
+
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"Reason": "Sending mail failed.",
+			})
+
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"Success": true,
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"Reason": "No feedback for this mail address.",
 	})
 }
 
-func (app *App) UpdateMailTemplate(c *gin.Context) {}
+// UpdateMailTemplate saves the text put in front of
+// or after the feedback in all mails to module owners.
+func (app *App) UpdateMailTemplate(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_SEND_FEEDBACK)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"Reason": "Not authorized.",
+		})
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	var Payload UpdateMailTemplatePayload
+
+	err = c.BindWith(&Payload, binding.FormPost)
+	if err != nil {
+
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Reason": "Internal error. Please try again later.",
+		})
+
+		return
+	}
+
+	// Name of template is part of the URL.
+	Payload.Name = c.Param("where")
+
+	// Check sent content for validity.
+	ErrorDesc := app.ConformAndValidate(&Payload)
+	if ErrorDesc != nil {
+
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Reason":            "Malformed input. Please check your values for validity and try again.",
+			"ErrorDescriptions": ErrorDesc,
+		})
+
+		return
+	}
+
+	if err := db.SaveMailTemplate(app.DB, Payload.Name, Payload.Content); err != nil {
+
+		log.Printf("[UpdateMailTemplate] Saving mail template '%s' failed: %s.\n", Payload.Name, err.Error())
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"Reason": "Internal error. Please try again later.",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Success": true,
+	})
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+
+	"net/http"
+
+	"github.com/freitagsrunde/modulist/db"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Structs
+
+type CannedCommentPayload struct {
+	Category int    `form:"canned-category" conform:"trim,num" validate:"min=0,max=13"`
+	Title    string `form:"canned-title" conform:"trim" validate:"required,max=100"`
+	Comment  string `form:"canned-comment" conform:"trim" validate:"required,max=5000"`
+}
+
+// Functions
+
+// renderCannedComments displays the library of canned
+// comments together with how often each one was used,
+// most common problems first.
+func (app *App) renderCannedComments(c *gin.Context, Code int, User *db.User, Data gin.H) {
+
+	CannedComments := db.ListCannedComments(app.DB)
+	Usage := db.LoadCannedCommentUsage(app.DB)
+
+	sort.SliceStable(CannedComments, func(i, j int) bool {
+		return Usage[CannedComments[i].ID].Comments > Usage[CannedComments[j].ID].Comments
+	})
+
+	Data["PageTitle"] = "Admin - Textbausteine"
+	Data["User"] = User
+	Data["CannedComments"] = CannedComments
+	Data["Usage"] = Usage
+	Data["Categories"] = db.CategoryTitles()
+
+	app.RenderHTML(c, Code, "admin-canned-comments.html", Data)
+}
+
+// loadCannedComment authorizes the user and loads the
+// canned comment referenced in the URL. If either fails,
+// the response is already sent.
+func (app *App) loadCannedComment(c *gin.Context) (*db.User, db.CannedComment, bool) {
+
+	var Comment db.CannedComment
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_MODERATE_FEEDBACK)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/")
+
+		return nil, Comment, false
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err == nil {
+		app.DB.First(&Comment, "\"id\" = ?", id)
+	}
+
+	if Comment.ID == 0 {
+		app.renderCannedComments(c, http.StatusNotFound, User, gin.H{
+			"FatalError": "Der Textbaustein existiert nicht.",
+		})
+
+		return nil, Comment, false
+	}
+
+	return User, Comment, true
+}
+
+// bindCannedComment reads and checks a submitted canned
+// comment. If it is malformed, the response is already sent.
+func (app *App) bindCannedComment(c *gin.Context, User *db.User) (CannedCommentPayload, bool) {
+
+	var Payload CannedCommentPayload
+
+	if err := c.BindWith(&Payload, binding.FormPost); err != nil {
+
+		app.renderCannedComments(c, http.StatusBadRequest, User, gin.H{
+			"FatalError": "Gesendete Daten für den Textbaustein konnten nicht verarbeitet werden. Bitte erneut versuchen.",
+		})
+
+		return Payload, false
+	}
+
+	// Check sent content for validity.
+	if ErrorDesc := app.ConformAndValidate(&Payload); ErrorDesc != nil {
+
+		app.renderCannedComments(c, http.StatusBadRequest, User, gin.H{
+			"Errors": ErrorDesc,
+		})
+
+		return Payload, false
+	}
+
+	return Payload, true
+}
+
+// ListCannedComments shows the library of canned comments.
+func (app *App) ListCannedComments(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_MODERATE_FEEDBACK)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/")
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	app.renderCannedComments(c, http.StatusOK, User, gin.H{})
+}
+
+// CreateCannedComment adds a snippet to the library.
+func (app *App) CreateCannedComment(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_MODERATE_FEEDBACK)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/")
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	Payload, ok := app.bindCannedComment(c, User)
+	if !ok {
+		return
+	}
+
+	app.DB.Create(&db.CannedComment{
+		Category: Payload.Category,
+		Title:    Payload.Title,
+		Comment:  Payload.Comment,
+	})
+
+	app.renderCannedComments(c, http.StatusOK, User, gin.H{
+		"Success": "Textbaustein wurde angelegt.",
+	})
+}
+
+// UpdateCannedComment changes a snippet of the library.
+// Feedback already created from it stays untouched.
+func (app *App) UpdateCannedComment(c *gin.Context) {
+
+	User, Comment, ok := app.loadCannedComment(c)
+	if !ok {
+		return
+	}
+
+	Payload, ok := app.bindCannedComment(c, User)
+	if !ok {
+		return
+	}
+
+	app.DB.Model(&Comment).Updates(map[string]interface{}{
+		"category": Payload.Category,
+		"title":    Payload.Title,
+		"comment":  Payload.Comment,
+	})
+
+	app.renderCannedComments(c, http.StatusOK, User, gin.H{
+		"Success": "Textbaustein wurde gespeichert.",
+	})
+}
+
+// DeleteCannedComment removes a snippet from the library.
+// Feedback created from it loses the reference.
+func (app *App) DeleteCannedComment(c *gin.Context) {
+
+	User, Comment, ok := app.loadCannedComment(c)
+	if !ok {
+		return
+	}
+
+	app.DB.Model(&db.Feedback{}).Where("\"canned_comment_id\" = ?", Comment.ID).Update("canned_comment_id", 0)
+	app.DB.Delete(&Comment)
+
+	app.renderCannedComments(c, http.StatusOK, User, gin.H{
+		"Success": "Textbaustein wurde gelöscht.",
+	})
+}
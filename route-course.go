@@ -0,0 +1,281 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"net/http"
+
+	"github.com/freitagsrunde/modulist/db"
+	"github.com/freitagsrunde/modulist/markdown"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Structs
+
+type AddCourseFeedbackPayload struct {
+	Comment  string `form:"comment" conform:"trim" validate:"required,max=20000"`
+	Severity string `form:"severity" conform:"trim" validate:"omitempty,eq=hint|eq=minor|eq=major|eq=blocking"`
+}
+
+// Functions
+
+// renderCourseComments renders the Markdown of
+// supplied comments on courses as HTML.
+func renderCourseComments(Feedback []db.CourseFeedback) {
+
+	for i := range Feedback {
+		Feedback[i].CommentHTML = markdown.ToHTML(Feedback[i].Comment)
+	}
+}
+
+// loadCourse loads the course with the ID from the URL.
+// It redirects to the list of courses and reports false
+// if no such course exists.
+func (app *App) loadCourse(c *gin.Context) (db.Course, bool) {
+
+	id, err := strconv.Atoi(c.Param("courseID"))
+	if err != nil {
+		c.Redirect(http.StatusFound, "/courses")
+
+		return db.Course{}, false
+	}
+
+	var Course db.Course
+	app.DB.First(&Course, "\"id\" = ?", id)
+
+	if Course.ID == 0 {
+		c.Redirect(http.StatusFound, "/courses")
+
+		return db.Course{}, false
+	}
+
+	return Course, true
+}
+
+// ListCourses lists all courses together with the
+// amount of modules including each of them.
+func (app *App) ListCourses(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_READ)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/")
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	var Courses []db.Course
+	app.DB.Order("\"title\" asc").Find(&Courses)
+
+	// Count modules including and feedback on each course.
+	type courseCount struct {
+		CourseID int
+		Count    int
+	}
+
+	var Counts []courseCount
+	app.DB.Table("module_courses").Select("\"course_id\", count(*) AS \"count\"").Group("\"course_id\"").Scan(&Counts)
+
+	Modules := make(map[int]int)
+	for _, Count := range Counts {
+		Modules[Count.CourseID] = Count.Count
+	}
+
+	var FeedbackCounts []courseCount
+	app.DB.Model(&db.CourseFeedback{}).Select("\"course_id\", count(*) AS \"count\"").Group("\"course_id\"").Scan(&FeedbackCounts)
+
+	Feedback := make(map[int]int)
+	for _, Count := range FeedbackCounts {
+		Feedback[Count.CourseID] = Count.Count
+	}
+
+	app.RenderHTML(c, http.StatusOK, "courses-list.html", gin.H{
+		"PageTitle": "Übersicht der Lehrveranstaltungen",
+		"User":      User,
+		"Courses":   Courses,
+		"Modules":   Modules,
+		"Feedback":  Feedback,
+	})
+}
+
+// renderCourse displays a course with the modules including
+// it, its working efforts and the feedback on it.
+func (app *App) renderCourse(c *gin.Context, Code int, User *db.User, Course db.Course, Data gin.H) {
+
+	var WorkingEfforts []db.WorkingEffort
+	app.DB.Where("\"course_id\" = ?", Course.ID).Order("\"id\" asc").Find(&WorkingEfforts)
+
+	Feedback := db.ListCourseFeedback(app.DB, Course.ID)
+	renderCourseComments(Feedback)
+
+	LinkStatus := db.LinkStatus{}
+	if Course.CourseURL.Valid {
+		CourseURL := strings.TrimSpace(Course.CourseURL.String)
+		LinkStatus = db.LoadLinkStatuses(app.DB, []string{CourseURL})[CourseURL]
+	}
+
+	Data["PageTitle"] = fmt.Sprintf("Lehrveranstaltung %s", Course.Title)
+	Data["User"] = User
+	Data["Course"] = Course
+	Data["Modules"] = db.ListCourseModules(app.DB, Course.ID)
+	Data["WorkingEfforts"] = db.WorkingEffortsConvert(WorkingEfforts)
+	Data["LinkStatus"] = LinkStatus
+	Data["Feedback"] = Feedback
+	Data["Severities"] = db.Severities()
+	Data["SeverityTitles"] = db.SeverityTitles()
+
+	app.RenderHTML(c, Code, "course.html", Data)
+}
+
+// ViewCourse shows all details of a course.
+func (app *App) ViewCourse(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_READ)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/")
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	Course, found := app.loadCourse(c)
+	if !found {
+		return
+	}
+
+	app.renderCourse(c, http.StatusOK, User, Course, gin.H{})
+}
+
+// AddCourseFeedback adds a comment on a course, which
+// applies to all modules including the course. Mentions,
+// watch digests and live updates of module pages only
+// cover feedback on modules, not this one.
+func (app *App) AddCourseFeedback(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_REVIEW)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/")
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	Course, found := app.loadCourse(c)
+	if !found {
+		return
+	}
+
+	var Payload AddCourseFeedbackPayload
+
+	if err := c.BindWith(&Payload, binding.FormPost); err != nil {
+
+		app.renderCourse(c, http.StatusBadRequest, User, Course, gin.H{
+			"FatalError": "Gesendetes Feedback konnte nicht verarbeitet werden. Bitte erneut versuchen.",
+		})
+
+		return
+	}
+
+	// Check sent content for validity.
+	if ErrorDesc := app.ConformAndValidate(&Payload); ErrorDesc != nil {
+
+		app.renderCourse(c, http.StatusBadRequest, User, Course, gin.H{
+			"Errors": ErrorDesc,
+		})
+
+		return
+	}
+
+	if Payload.Severity == "" {
+		Payload.Severity = db.SEVERITY_MINOR
+	}
+
+	Feedback := db.CourseFeedback{
+		CourseID: Course.ID,
+		UserID:   User.ID,
+		Comment:  Payload.Comment,
+		Severity: Payload.Severity,
+		Created:  time.Now(),
+	}
+
+	// The course already exists, only store the reference.
+	if err := app.DB.Set("gorm:save_associations", false).Create(&Feedback).Error; err != nil {
+
+		app.renderCourse(c, http.StatusInternalServerError, User, Course, gin.H{
+			"FatalError": "Feedback konnte nicht gespeichert werden. Erneut versuchen oder Admin kontaktieren.",
+		})
+
+		return
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/review/course/%d#course-feedback-%d", Course.ID, Feedback.ID))
+}
+
+// DeleteCourseFeedback removes a comment on a course.
+// Reviewers may delete their own comments, users allowed
+// to moderate feedback may delete any. As when adding,
+// open module pages are not updated.
+func (app *App) DeleteCourseFeedback(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_REVIEW)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/")
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	Course, found := app.loadCourse(c)
+	if !found {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Redirect(http.StatusFound, fmt.Sprintf("/review/course/%d", Course.ID))
+
+		return
+	}
+
+	var Feedback db.CourseFeedback
+	app.DB.First(&Feedback, "\"id\" = ? AND \"course_id\" = ?", id, Course.ID)
+
+	if Feedback.ID == 0 {
+
+		app.renderCourse(c, http.StatusBadRequest, User, Course, gin.H{
+			"FatalError": "Das Feedback existiert nicht.",
+		})
+
+		return
+	}
+
+	// Only authors and moderators may delete feedback.
+	if (Feedback.UserID != User.ID) && !User.Can(db.PERMISSION_MODERATE_FEEDBACK) {
+
+		app.renderCourse(c, http.StatusForbidden, User, Course, gin.H{
+			"FatalError": "Nur eigenes Feedback kann gelöscht werden.",
+		})
+
+		return
+	}
+
+	app.DB.Delete(&Feedback)
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/review/course/%d", Course.ID))
+}
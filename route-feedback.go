@@ -9,6 +9,10 @@ import (
 	"net/http"
 
 	"github.com/freitagsrunde/modulist/db"
+	"github.com/freitagsrunde/modulist/lint"
+	"github.com/freitagsrunde/modulist/markdown"
+	"github.com/freitagsrunde/modulist/readability"
+	"github.com/freitagsrunde/modulist/workload"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 )
@@ -21,15 +25,135 @@ type ReviewModulePayload struct {
 
 type AddFeedbackPayload struct {
 	Category int    `form:"category" conform:"trim,num" validate:"min=0"`
-	Comment  string `form:"comment" conform:"trim" validate:"required"`
+	Comment  string `form:"comment" conform:"trim" validate:"max=20000"`
+	Parent   int    `form:"parent" conform:"trim,num" validate:"min=0"`
+	Kind     string `form:"kind" conform:"trim" validate:"omitempty,eq=comment|eq=suggestion"`
+	Canned   int    `form:"canned" conform:"trim,num" validate:"min=0"`
+	Severity string `form:"severity" conform:"trim" validate:"omitempty,eq=hint|eq=minor|eq=major|eq=blocking"`
+	Tags     string `form:"tags" validate:"max=200"`
+
+	// Passage of a free text field the comment refers to.
+	AnchorField string `form:"anchor-field" conform:"trim" validate:"max=50"`
+	AnchorStart int    `form:"anchor-start" validate:"min=0"`
+	AnchorEnd   int    `form:"anchor-end" validate:"min=0"`
+	AnchorQuote string `form:"anchor-quote"`
+
+	// Replacement text proposed for a free text field.
+	SuggestionField string `form:"suggestion-field" conform:"trim" validate:"max=50"`
+	Suggestion      string `form:"suggestion" validate:"max=20000"`
+}
+
+type ClassifyFeedbackPayload struct {
+	Severity string `form:"severity" conform:"trim" validate:"omitempty,eq=hint|eq=minor|eq=major|eq=blocking"`
+	Status   string `form:"status" conform:"trim" validate:"omitempty,eq=open|eq=accepted|eq=rejected|eq=resolved"`
+	Tags     string `form:"tags" validate:"max=200"`
+}
+
+type FeedbackFilterPayload struct {
+	Severities []string `form:"severity" validate:"dive,eq=hint|eq=minor|eq=major|eq=blocking"`
+	Statuses   []string `form:"status" validate:"dive,eq=open|eq=accepted|eq=rejected|eq=resolved"`
+	Tag        string   `form:"tag" conform:"trim,lower" validate:"max=50"`
+	MinVotes   int      `form:"min-votes" validate:"min=0"`
 }
 
 // Functions
 
+// textToHTML escapes supplied text and converts its line
+// breaks into HTML line breaks. The line breaks themselves
+// are kept, so that the text content of the resulting HTML
+// equals the text and anchors of feedback can be located.
+func textToHTML(Text string) template.HTML {
+	return template.HTML(strings.Replace(template.HTMLEscapeString(db.NormalizeLineBreaks(Text)), "\n", "<br />\n", -1))
+}
+
+// renderComments renders the Markdown of all comments
+// and their replies in supplied discussions as HTML.
+func renderComments(Threads []db.Feedback) {
+
+	for i := range Threads {
+
+		Threads[i].CommentHTML = markdown.ToHTML(Threads[i].Comment)
+
+		for j := range Threads[i].Replies {
+			Threads[i].Replies[j].CommentHTML = markdown.ToHTML(Threads[i].Replies[j].Comment)
+		}
+	}
+}
+
+// Filter converts the payload into a filter on feedback.
+func (payload FeedbackFilterPayload) Filter() db.FeedbackFilter {
+
+	return db.FeedbackFilter{
+		Severities: payload.Severities,
+		Statuses:   payload.Statuses,
+		Tag:        payload.Tag,
+		MinVotes:   payload.MinVotes,
+	}
+}
+
+// filterCategory returns the discussions of supplied
+// category, a negative category returns all of them.
+func filterCategory(Threads []db.Feedback, Category int) []db.Feedback {
+
+	if Category < 0 {
+		return Threads
+	}
+
+	Filtered := []db.Feedback{}
+	for _, Thread := range Threads {
+
+		if Thread.Category == Category {
+			Filtered = append(Filtered, Thread)
+		}
+	}
+
+	return Filtered
+}
+
+// respondThreads answers with all discussions on a module
+// selected by supplied filter, or only those of one category,
+// together with the amount of comments per category and what
+// the user is allowed to do with them. Comments supported by
+// most reviewers are listed first.
+func (app *App) respondThreads(c *gin.Context, User *db.User, ModuleID int, Category int, Filter db.FeedbackFilter) {
+
+	Threads := db.ListThreads(app.DB, ModuleID)
+	db.LoadVotes(app.DB, Threads, User.ID)
+	db.SortBySupport(Threads)
+	Threads = db.FilterThreads(Threads, Filter)
+
+	// Locate anchored passages in the current module text.
+	var Module db.Module
+	app.DB.First(&Module, "\"id\" = ?", ModuleID)
+	ResolveAnchors(Module, Threads)
+	ResolveSuggestions(Module, Threads)
+	renderComments(Threads)
+
+	Counts := make(map[int]int)
+	for _, Thread := range Threads {
+		Counts[Thread.Category]++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Success":       true,
+		"Feedback":      filterCategory(Threads, Category),
+		"Counts":        Counts,
+		"UserID":        User.ID,
+		"CanReview":     User.Can(db.PERMISSION_REVIEW),
+		"CanModerate":   User.Can(db.PERMISSION_MODERATE_FEEDBACK),
+		"Severities":    db.SeverityTitles(),
+		"SeverityOrder": db.Severities(),
+		"Statuses":      db.StatusTitles(),
+		"StatusOrder":   db.Statuses(),
+		"Fields":        db.FieldTitles(),
+		"StatusGroups":  db.StatusGroupTitles(),
+	})
+}
+
 func (app *App) ReviewModule(c *gin.Context) {
 
 	// Check if user is authorized.
-	User, err := app.Authorize(c.Request, db.PRIVILEGE_REVIEWER)
+	User, err := app.Authorize(c.Request, db.PERMISSION_READ)
 	if err != nil {
 		c.Redirect(http.StatusFound, "/")
 
@@ -85,30 +209,65 @@ func (app *App) ReviewModule(c *gin.Context) {
 
 	// For each of the elements containing free text with linebreaks,
 	// convert each of those to HTML linebreaks in a safely manner.
-	Module.LearningOutcomesHTML = template.HTML(strings.Replace(template.HTMLEscapeString(Module.LearningOutcomes.String), "\n", "<br />", -1))
-	Module.LearningOutcomesEnglishHTML = template.HTML(strings.Replace(template.HTMLEscapeString(Module.LearningOutcomesEnglish.String), "\n", "<br />", -1))
-	Module.TeachingContentsHTML = template.HTML(strings.Replace(template.HTMLEscapeString(Module.TeachingContents.String), "\n", "<br />", -1))
-	Module.TeachingContentsEnglishHTML = template.HTML(strings.Replace(template.HTMLEscapeString(Module.TeachingContentsEnglish.String), "\n", "<br />", -1))
-	Module.InstructiveFormHTML = template.HTML(strings.Replace(template.HTMLEscapeString(Module.InstructiveForm), "\n", "<br />", -1))
-	Module.OptionalRequirementsHTML = template.HTML(strings.Replace(template.HTMLEscapeString(Module.OptionalRequirements), "\n", "<br />", -1))
-	Module.MandatoryRequirementsHTML = template.HTML(strings.Replace(template.HTMLEscapeString(Module.MandatoryRequirements.String), "\n", "<br />", -1))
-	Module.ExaminationDescriptionHTML = template.HTML(strings.Replace(template.HTMLEscapeString(Module.ExaminationDescription.String), "\n", "<br />", -1))
-	Module.MiscellaneousHTML = template.HTML(strings.Replace(template.HTMLEscapeString(Module.Miscellaneous.String), "\n", "<br />", -1))
-	Module.LiteratureHTML = template.HTML(strings.Replace(template.HTMLEscapeString(Module.Literature), "\n", "<br />", -1))
-	Module.RegistrationFormalitiesHTML = template.HTML(strings.Replace(template.HTMLEscapeString(Module.RegistrationFormalities.String), "\n", "<br />", -1))
-
-	c.HTML(http.StatusOK, "module-feedback.html", gin.H{
-		"PageTitle":  fmt.Sprintf("Feedback zu Modul #%d", Module.ModuleID),
-		"User":       User,
-		"Module":     Module,
-		"Categories": db.CategoriesByName(),
+	Module.LearningOutcomesHTML = textToHTML(Module.LearningOutcomes.String)
+	Module.LearningOutcomesEnglishHTML = textToHTML(Module.LearningOutcomesEnglish.String)
+	Module.TeachingContentsHTML = textToHTML(Module.TeachingContents.String)
+	Module.TeachingContentsEnglishHTML = textToHTML(Module.TeachingContentsEnglish.String)
+	Module.InstructiveFormHTML = textToHTML(Module.InstructiveForm)
+	Module.OptionalRequirementsHTML = textToHTML(Module.OptionalRequirements)
+	Module.MandatoryRequirementsHTML = textToHTML(Module.MandatoryRequirements.String)
+	Module.ExaminationDescriptionHTML = textToHTML(Module.ExaminationDescription.String)
+	Module.MiscellaneousHTML = textToHTML(Module.Miscellaneous.String)
+	Module.LiteratureHTML = textToHTML(Module.Literature)
+	Module.RegistrationFormalitiesHTML = textToHTML(Module.RegistrationFormalities.String)
+
+	// Run plausibility checks. Findings already adopted
+	// as comment are recognized by their unchanged text.
+	var Comments []string
+	app.DB.Model(&db.Feedback{}).Where("\"module_id\" = ? AND \"parent_id\" = ?", Module.ID, 0).Pluck("\"comment\"", &Comments)
+
+	AdoptedFindings := make(map[string]bool)
+	for _, Comment := range Comments {
+		AdoptedFindings[Comment] = true
+	}
+
+	RequiredModules, MissingRequirements := app.LoadRequiredModules(Module.ID)
+
+	// Broken links are reported among the automatic findings.
+	Module.LinkStatuses = db.LoadLinkStatuses(app.DB, Module.Links())
+
+	// Feedback on courses applies to all modules including them.
+	CourseFeedback := db.ListCourseFeedback(app.DB, Module.CourseIDs()...)
+	renderCourseComments(CourseFeedback)
+
+	app.RenderHTML(c, http.StatusOK, "module-feedback.html", gin.H{
+		"PageTitle":           fmt.Sprintf("Feedback zu Modul #%d", Module.ModuleID),
+		"User":                User,
+		"Module":              Module,
+		"Categories":          db.CategoriesByName(),
+		"CannedComments":      db.CannedCommentsByCategory(app.DB),
+		"Severities":          db.Severities(),
+		"SeverityTitles":      db.SeverityTitles(),
+		"Statuses":            db.Statuses(),
+		"StatusTitles":        db.StatusTitles(),
+		"Watching":            db.IsWatching(app.DB, *User, Module.ID),
+		"Findings":            lint.Check(Module),
+		"AdoptedFindings":     AdoptedFindings,
+		"CategoryTitles":      db.CategoryTitles(),
+		"Workload":            workload.Calculate(Module),
+		"RequiredModules":     RequiredModules,
+		"MissingRequirements": MissingRequirements,
+		"SimilarModules":      app.FindSimilarModules(Module),
+		"Readability":         readability.Analyze(Module),
+		"LanguageTitles":      readability.LanguageTitles(),
+		"CourseFeedback":      CourseFeedback,
 	})
 }
 
 func (app *App) AddFeedback(c *gin.Context) {
 
 	// Check if user is authorized.
-	User, err := app.Authorize(c.Request, db.PRIVILEGE_REVIEWER)
+	User, err := app.Authorize(c.Request, db.PERMISSION_REVIEW)
 	if err != nil {
 		c.Redirect(http.StatusFound, "/")
 
@@ -174,6 +333,94 @@ func (app *App) AddFeedback(c *gin.Context) {
 		return
 	}
 
+	// Replies can only be given to top-level comments
+	// and belong to the category of the comment.
+	if FeedbackPayload.Parent != 0 {
+
+		var Parent db.Feedback
+		app.DB.First(&Parent, "\"id\" = ? AND \"module_id\" = ? AND \"parent_id\" = ?", FeedbackPayload.Parent, IDPayload.ID, 0)
+
+		if Parent.ID == 0 {
+
+			c.JSON(http.StatusBadRequest, gin.H{
+				"Reason": "Feedback to reply to does not exist.",
+			})
+
+			return
+		}
+
+		FeedbackPayload.Category = Parent.Category
+
+		// Only comments themselves are classified, anchored
+		// or propose changes.
+		FeedbackPayload.Severity = ""
+		FeedbackPayload.Tags = ""
+		FeedbackPayload.AnchorField = ""
+		FeedbackPayload.Kind = db.KIND_COMMENT
+		FeedbackPayload.Canned = 0
+	}
+
+	// Only count usage of canned comments that exist.
+	if FeedbackPayload.Canned != 0 {
+
+		var Canned db.CannedComment
+		app.DB.First(&Canned, "\"id\" = ?", FeedbackPayload.Canned)
+
+		FeedbackPayload.Canned = Canned.ID
+	}
+
+	// Suggestions replace a whole field, the text of the
+	// field is kept to compare the proposal with later on.
+	// The comment only explains them and may be left out.
+	SuggestionOriginal := ""
+
+	if FeedbackPayload.Kind == db.KIND_SUGGESTION {
+
+		Category, ok := db.AnchorFields()[FeedbackPayload.SuggestionField]
+		SuggestionOriginal, _ = Module.AnchorText(FeedbackPayload.SuggestionField)
+		FeedbackPayload.Suggestion = db.NormalizeLineBreaks(FeedbackPayload.Suggestion)
+
+		if !ok || (FeedbackPayload.Suggestion == SuggestionOriginal) || (len(FeedbackPayload.Suggestion) > MAX_SUGGESTION_LENGTH) {
+
+			c.JSON(http.StatusBadRequest, gin.H{
+				"Reason": "Suggestion does not propose a change to a field of the module description.",
+			})
+
+			return
+		}
+
+		FeedbackPayload.Category = Category
+		FeedbackPayload.AnchorField = ""
+	} else if FeedbackPayload.Comment == "" {
+
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Reason": "Malformed input. Please check your values for validity and try again.",
+		})
+
+		return
+	}
+
+	// Anchored comments have to quote the passage of the
+	// current module text they refer to exactly.
+	if FeedbackPayload.AnchorField != "" {
+
+		Category, ok := db.AnchorFields()[FeedbackPayload.AnchorField]
+		Text, _ := Module.AnchorText(FeedbackPayload.AnchorField)
+		Passage, inText := AnchorSlice(Text, FeedbackPayload.AnchorStart, FeedbackPayload.AnchorEnd)
+
+		if !ok || !inText || (Passage != FeedbackPayload.AnchorQuote) || ((FeedbackPayload.AnchorEnd - FeedbackPayload.AnchorStart) > MAX_ANCHOR_LENGTH) {
+
+			c.JSON(http.StatusBadRequest, gin.H{
+				"Reason": "Selected passage is not part of the module description.",
+			})
+
+			return
+		}
+
+		// Comments belong to the category displaying the passage.
+		FeedbackPayload.Category = Category
+	}
+
 	// With supplied content, create new feedback.
 	var NewFeedback db.Feedback
 
@@ -181,29 +428,328 @@ func (app *App) AddFeedback(c *gin.Context) {
 	NewFeedback.UserID = User.ID
 	NewFeedback.Category = FeedbackPayload.Category
 	NewFeedback.Comment = FeedbackPayload.Comment
+	NewFeedback.ParentID = FeedbackPayload.Parent
+	NewFeedback.Severity = FeedbackPayload.Severity
+	NewFeedback.Status = db.STATUS_OPEN
+	NewFeedback.Tags = db.NormalizeTags(FeedbackPayload.Tags)
+	NewFeedback.CannedCommentID = FeedbackPayload.Canned
+
+	if FeedbackPayload.Kind == db.KIND_SUGGESTION {
+		NewFeedback.Kind = db.KIND_SUGGESTION
+		NewFeedback.SuggestionField = FeedbackPayload.SuggestionField
+		NewFeedback.SuggestionOriginal = SuggestionOriginal
+		NewFeedback.Suggestion = FeedbackPayload.Suggestion
+	} else {
+		NewFeedback.Kind = db.KIND_COMMENT
+	}
+
+	if FeedbackPayload.AnchorField != "" {
+		NewFeedback.AnchorField = FeedbackPayload.AnchorField
+		NewFeedback.AnchorStart = FeedbackPayload.AnchorStart
+		NewFeedback.AnchorEnd = FeedbackPayload.AnchorEnd
+		NewFeedback.AnchorQuote = FeedbackPayload.AnchorQuote
+	}
+
+	if NewFeedback.Severity == "" {
+		NewFeedback.Severity = db.SEVERITY_MINOR
+	}
 
 	// Save feedback to database.
 	app.DB.Create(&NewFeedback)
 
-	// Request all feedback comments for submitted
-	// category and include count of those.
-	var AllFeedback []db.Feedback
-	app.DB.Order("\"id\" asc").Find(&AllFeedback, "\"module_id\" = ? AND \"category\" = ?", IDPayload.ID, FeedbackPayload.Category)
+	// Let mentioned users know about the new feedback.
+	app.NotifyMentions(*User, NewFeedback)
+
+	// Commenting on a module lets the author watch it.
+	Event := db.EVENT_COMMENT
+	if NewFeedback.ParentID != 0 {
+		Event = db.EVENT_REPLY
+	}
+
+	app.RecordEvent(*User, NewFeedback, Event, "")
+	db.AutoWatch(app.DB, User.ID, NewFeedback.ModuleID)
+	app.publishFeedback(FEEDBACK_CREATED, *User, NewFeedback)
+
+	// Return all discussions of submitted category.
+	app.respondThreads(c, User, IDPayload.ID, FeedbackPayload.Category, db.FeedbackFilter{})
+}
+
+// MarkConsensus marks a reply as the consensus its
+// discussion resolved into, or removes that mark again.
+// Each discussion has at most one consensus.
+func (app *App) MarkConsensus(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_MODERATE_FEEDBACK)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"Reason": "Not authorized.",
+		})
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	// Extract IDs of module and reply from URL.
+	moduleID, errModule := strconv.Atoi(c.Param("moduleID"))
+	feedbackID, errFeedback := strconv.Atoi(c.Param("id"))
+	if (errModule != nil) || (errFeedback != nil) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Reason": "Malformed IDs.",
+		})
+
+		return
+	}
+
+	var Reply db.Feedback
+	app.DB.First(&Reply, "\"id\" = ? AND \"module_id\" = ? AND \"parent_id\" <> ?", feedbackID, moduleID, 0)
+
+	// Only replies can be a consensus.
+	if Reply.ID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Reason": "Reply does not exist.",
+		})
+
+		return
+	}
+
+	// Remove any former consensus of this discussion.
+	app.DB.Model(&db.Feedback{}).Where("\"parent_id\" = ?", Reply.ParentID).Update("consensus", false)
+
+	if !Reply.Consensus {
+		app.DB.Model(&Reply).Update("consensus", true)
+		app.RecordEvent(*User, Reply, db.EVENT_CONSENSUS, "")
+	}
+
+	app.publishFeedback(FEEDBACK_EDITED, *User, Reply)
+
+	app.respondThreads(c, User, moduleID, Reply.Category, db.FeedbackFilter{})
+}
+
+// VoteFeedback adds the vote of the logged-in reviewer
+// to a comment of another reviewer, or withdraws it.
+func (app *App) VoteFeedback(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_REVIEW)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"Reason": "Not authorized.",
+		})
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	// Extract IDs of module and comment from URL.
+	moduleID, errModule := strconv.Atoi(c.Param("moduleID"))
+	feedbackID, errFeedback := strconv.Atoi(c.Param("id"))
+	if (errModule != nil) || (errFeedback != nil) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Reason": "Malformed IDs.",
+		})
+
+		return
+	}
+
+	var Feedback db.Feedback
+	app.DB.First(&Feedback, "\"id\" = ? AND \"module_id\" = ? AND \"parent_id\" = ?", feedbackID, moduleID, 0)
+
+	// Only top-level comments of others can be voted for.
+	if (Feedback.ID == 0) || (Feedback.UserID == User.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Reason": "Comment does not exist or is your own.",
+		})
+
+		return
+	}
+
+	db.ToggleVote(app.DB, Feedback.ID, User.ID)
+	app.publishFeedback(FEEDBACK_EDITED, *User, Feedback)
+
+	app.respondThreads(c, User, moduleID, Feedback.Category, db.FeedbackFilter{})
+}
+
+// ClassifyFeedback updates severity, tags and status of a
+// comment. Authors may change severity and tags of their
+// own comments, moderators may change everything.
+func (app *App) ClassifyFeedback(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_REVIEW)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"Reason": "Not authorized.",
+		})
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	// Extract IDs of module and comment from URL.
+	moduleID, errModule := strconv.Atoi(c.Param("moduleID"))
+	feedbackID, errFeedback := strconv.Atoi(c.Param("id"))
+	if (errModule != nil) || (errFeedback != nil) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Reason": "Malformed IDs.",
+		})
+
+		return
+	}
+
+	var Feedback db.Feedback
+	app.DB.First(&Feedback, "\"id\" = ? AND \"module_id\" = ? AND \"parent_id\" = ?", feedbackID, moduleID, 0)
+
+	// Only top-level comments are classified.
+	if Feedback.ID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Reason": "Feedback does not exist.",
+		})
+
+		return
+	}
+
+	var Payload ClassifyFeedbackPayload
+
+	err = c.BindWith(&Payload, binding.FormPost)
+	if err != nil {
+
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Reason": "Internal error. Please try again later.",
+		})
+
+		return
+	}
+
+	// Check sent content for validity.
+	ErrorDesc := app.ConformAndValidate(&Payload)
+	if ErrorDesc != nil {
+
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Reason":            "Malformed input. Please check your values for validity and try again.",
+			"ErrorDescriptions": ErrorDesc,
+		})
+
+		return
+	}
+
+	canModerate := User.Can(db.PERMISSION_MODERATE_FEEDBACK)
+
+	if ((Feedback.UserID != User.ID) && !canModerate) || ((Payload.Status != "") && !canModerate) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"Reason": "You do not have sufficient privileges.",
+		})
+
+		return
+	}
+
+	Changes := map[string]interface{}{}
+
+	// Forms without tag field leave the tags untouched,
+	// while an empty tag field removes all tags.
+	if _, sent := c.GetPostForm("tags"); sent {
+		Changes["tags"] = db.NormalizeTags(Payload.Tags)
+	}
+
+	if Payload.Severity != "" {
+		Changes["severity"] = Payload.Severity
+	}
+
+	if Payload.Status != "" {
+		Changes["status"] = Payload.Status
+	}
+
+	if len(Changes) > 0 {
+		app.DB.Model(&Feedback).Updates(Changes)
+	}
+
+	if (Payload.Status != "") && (Payload.Status != Feedback.Status) {
+		app.RecordEvent(*User, Feedback, db.EVENT_STATUS, Payload.Status)
+	}
+
+	app.publishFeedback(FEEDBACK_EDITED, *User, Feedback)
+
+	app.respondThreads(c, User, moduleID, Feedback.Category, db.FeedbackFilter{})
+}
+
+// DeleteFeedback removes a feedback element. Reviewers
+// may delete their own feedback, users allowed to moderate
+// feedback may delete any.
+func (app *App) DeleteFeedback(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_REVIEW)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"Reason": "Not authorized.",
+		})
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	// Extract IDs of module and feedback from URL.
+	moduleID, errModule := strconv.Atoi(c.Param("moduleID"))
+	feedbackID, errFeedback := strconv.Atoi(c.Param("id"))
+	if (errModule != nil) || (errFeedback != nil) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Reason": "Malformed IDs.",
+		})
+
+		return
+	}
+
+	var Feedback db.Feedback
+	app.DB.First(&Feedback, "\"id\" = ? AND \"module_id\" = ?", feedbackID, moduleID)
+
+	// Check if feedback exists for this module.
+	if Feedback.ID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Reason": "Feedback does not exist.",
+		})
+
+		return
+	}
+
+	// Only authors and moderators may delete feedback.
+	if (Feedback.UserID != User.ID) && !User.Can(db.PERMISSION_MODERATE_FEEDBACK) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"Reason": "You do not have sufficient privileges.",
+		})
+
+		return
+	}
+
+	// Replies lose their context together with the comment,
+	// so do notifications and events about any of them.
+	var ReplyIDs []int
+	app.DB.Model(&db.Feedback{}).Where("\"parent_id\" = ?", Feedback.ID).Pluck("\"id\"", &ReplyIDs)
+
+	app.DB.Delete(db.Notification{}, "\"feedback_id\" IN (?)", append(ReplyIDs, Feedback.ID))
+	app.DB.Delete(db.ModuleEvent{}, "\"feedback_id\" IN (?)", append(ReplyIDs, Feedback.ID))
+	app.DB.Delete(db.Feedback{}, "\"parent_id\" = ?", Feedback.ID)
+	app.DB.Delete(db.FeedbackVote{}, "\"feedback_id\" = ?", Feedback.ID)
+	app.DB.Delete(&Feedback)
+
+	app.publishFeedback(FEEDBACK_DELETED, *User, Feedback)
 
-	// Return success as JSON to user.
 	c.JSON(http.StatusOK, gin.H{
-		"Success":  true,
-		"Feedback": AllFeedback,
-		"Count":    len(AllFeedback),
+		"Success": true,
 	})
 }
 
-func (app *App) DeleteFeedback(c *gin.Context) {}
-
 func (app *App) ListFeedback(c *gin.Context) {
 
 	// Check if user is authorized.
-	User, err := app.Authorize(c.Request, db.PRIVILEGE_REVIEWER)
+	User, err := app.Authorize(c.Request, db.PERMISSION_READ)
 	if err != nil {
 		c.Redirect(http.StatusFound, "/")
 
@@ -244,15 +790,31 @@ func (app *App) ListFeedback(c *gin.Context) {
 		return
 	}
 
-	// Fetch all available feedback for that module,
-	// calculate counts and return data to user.
-	var AllFeedback []db.Feedback
-	app.DB.Order("\"category\" asc").Order("\"id\" asc").Find(&AllFeedback, "\"module_id\" = ?", Payload.ID)
+	var FilterPayload FeedbackFilterPayload
 
-	// TODO: Add calculation of counters per category.
+	err = c.BindWith(&FilterPayload, binding.Form)
+	if err != nil {
 
-	c.JSON(http.StatusOK, gin.H{
-		"Success":  true,
-		"Feedback": AllFeedback,
-	})
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Reason": "Internal error. Please try again later.",
+		})
+
+		return
+	}
+
+	// Check supplied filter for validity.
+	ErrorDesc := app.ConformAndValidate(&FilterPayload)
+	if ErrorDesc != nil {
+
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Reason":            "Malformed input. Please check your values for validity and try again.",
+			"ErrorDescriptions": ErrorDesc,
+		})
+
+		return
+	}
+
+	// Return all discussions on that module matching the
+	// filter with replies nested below each comment.
+	app.respondThreads(c, User, Payload.ID, -1, FilterPayload.Filter())
 }
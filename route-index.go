@@ -3,10 +3,8 @@ package main
 import (
 	"net/http"
 
-	"github.com/freitagsrunde/modulist/db"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // Structs
@@ -23,16 +21,17 @@ type LoginPayload struct {
 func (app *App) Index(c *gin.Context) {
 
 	// Check if user is already logged in.
-	_, err := app.Authorize(c.Request, db.PRIVILEGE_REVIEWER)
+	User, err := app.Authorize(c.Request, "")
 	if err == nil {
-		c.Redirect(http.StatusFound, "/modules")
+		c.Redirect(http.StatusFound, LandingPage(*User))
 
 		return
 	}
 
-	c.HTML(http.StatusOK, "index.html", gin.H{
-		"PageTitle": "Willkommen bei MODULIST",
-		"MainTitle": "Willkommen bei MODULIST",
+	app.RenderHTML(c, http.StatusOK, "index.html", gin.H{
+		"PageTitle":   "Willkommen bei MODULIST",
+		"MainTitle":   "Willkommen bei MODULIST",
+		"OIDCEnabled": (app.OIDC != nil),
 	})
 
 	return
@@ -43,9 +42,9 @@ func (app *App) Index(c *gin.Context) {
 func (app *App) Login(c *gin.Context) {
 
 	// Check if user is already logged in.
-	_, err := app.Authorize(c.Request, db.PRIVILEGE_REVIEWER)
+	User, err := app.Authorize(c.Request, "")
 	if err == nil {
-		c.Redirect(http.StatusFound, "/modules")
+		c.Redirect(http.StatusFound, LandingPage(*User))
 
 		return
 	}
@@ -55,10 +54,11 @@ func (app *App) Login(c *gin.Context) {
 	err = c.BindWith(&Payload, binding.FormPost)
 	if err != nil {
 
-		c.HTML(http.StatusBadRequest, "index.html", gin.H{
-			"PageTitle":  "Willkommen bei MODULIST",
-			"MainTitle":  "Willkommen bei MODULIST",
-			"FatalError": "Gesendete Logindaten konnten nicht verarbeitet werden. Bitte erneut versuchen.",
+		app.RenderHTML(c, http.StatusBadRequest, "index.html", gin.H{
+			"PageTitle":   "Willkommen bei MODULIST",
+			"MainTitle":   "Willkommen bei MODULIST",
+			"OIDCEnabled": (app.OIDC != nil),
+			"FatalError":  "Gesendete Logindaten konnten nicht verarbeitet werden. Bitte erneut versuchen.",
 		})
 
 		return
@@ -69,39 +69,36 @@ func (app *App) Login(c *gin.Context) {
 	if ErrorDesc != nil {
 
 		// If payload did not pass, report errors to user.
-		c.HTML(http.StatusBadRequest, "index.html", gin.H{
-			"PageTitle": "Willkommen bei MODULIST",
-			"MainTitle": "Willkommen bei MODULIST",
-			"Errors":    ErrorDesc,
+		app.RenderHTML(c, http.StatusBadRequest, "index.html", gin.H{
+			"PageTitle":   "Willkommen bei MODULIST",
+			"MainTitle":   "Willkommen bei MODULIST",
+			"OIDCEnabled": (app.OIDC != nil),
+			"Errors":      ErrorDesc,
 		})
 
 		return
 	}
 
-	// Data is valid, try to locate user in database.
-	var User db.User
-	app.DB.First(&User, "\"mail\" = ? AND \"enabled\" = ?", Payload.Mail, true)
-
-	// Compare password hash from database with possible plaintext
-	// password from submitted login form. Compares in constant time.
-	err = bcrypt.CompareHashAndPassword([]byte(User.PasswordHash), []byte(Payload.Password))
-	if (User.ID == "") || (err != nil) {
+	// Data is valid, let the configured backends authenticate the user.
+	User, err = app.Authenticator.Authenticate(Payload.Mail, Payload.Password)
+	if err != nil {
 
 		// Signal client that an error occured.
-		c.HTML(http.StatusBadRequest, "index.html", gin.H{
-			"PageTitle":  "Willkommen bei MODULIST",
-			"MainTitle":  "Willkommen bei MODULIST",
-			"FatalError": "Mail und/oder Passwort falsch.",
+		app.RenderHTML(c, http.StatusBadRequest, "index.html", gin.H{
+			"PageTitle":   "Willkommen bei MODULIST",
+			"MainTitle":   "Willkommen bei MODULIST",
+			"OIDCEnabled": (app.OIDC != nil),
+			"FatalError":  "Mail und/oder Passwort falsch.",
 		})
 
 		return
 	}
 
 	// Create a JWT and store it as a cookie.
-	app.CreateSession(c, User)
+	app.CreateSession(c, *User)
 
 	// Redirect to first authorized page.
-	c.Redirect(http.StatusFound, "/modules")
+	c.Redirect(http.StatusFound, LandingPage(*User))
 }
 
 // Logout destroys the user's session by storing
@@ -110,7 +107,7 @@ func (app *App) Login(c *gin.Context) {
 func (app *App) Logout(c *gin.Context) {
 
 	// Check if user is authorized.
-	_, err := app.Authorize(c.Request, db.PRIVILEGE_REVIEWER)
+	_, err := app.Authorize(c.Request, "")
 	if err != nil {
 		c.Redirect(http.StatusFound, "/")
 
@@ -119,7 +116,7 @@ func (app *App) Logout(c *gin.Context) {
 
 	// Set token cookie content to garbage and
 	// expiration date to a date in the past.
-	c.SetCookie("Token", "", -1, "", "", false, true)
+	app.SetCookie(c, "Token", "", -1, "/", app.CookieSameSite)
 
 	// Redirect back to index page.
 	c.Redirect(http.StatusFound, "/")
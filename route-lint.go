@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/freitagsrunde/modulist/db"
+	"github.com/freitagsrunde/modulist/lint"
+	"github.com/gin-gonic/gin"
+)
+
+// Functions
+
+// ListLintReport runs the plausibility checks over all
+// modules and lists them ordered by amount of findings,
+// telling reviewers where to start.
+func (app *App) ListLintReport(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_MODERATE_FEEDBACK)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/")
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	Reports := lint.CheckAll(db.LoadCatalogue(app.DB))
+
+	// Modules without findings need no attention.
+	Total := 0
+	WithFindings := []lint.ModuleReport{}
+	for _, Report := range Reports {
+
+		if len(Report.Findings) > 0 {
+			WithFindings = append(WithFindings, Report)
+			Total += len(Report.Findings)
+		}
+	}
+
+	app.RenderHTML(c, http.StatusOK, "admin-lint.html", gin.H{
+		"PageTitle":      "Admin - Plausibilitätsbericht",
+		"User":           User,
+		"Reports":        WithFindings,
+		"Modules":        len(Reports),
+		"Findings":       Total,
+		"Severities":     db.Severities(),
+		"SeverityTitles": db.SeverityTitles(),
+		"CategoryTitles": db.CategoryTitles(),
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/freitagsrunde/modulist/db"
+	"github.com/freitagsrunde/modulist/readability"
 	"github.com/gin-gonic/gin"
 	"github.com/leebenson/conform"
 )
@@ -16,10 +17,22 @@ type SearchPayload struct {
 
 // Functions
 
+// analyzeModules scores the readability of supplied
+// modules to be displayed in the list, keyed by ID.
+func analyzeModules(Modules []db.Module) map[int]readability.Report {
+
+	Reports := make(map[int]readability.Report)
+	for _, Module := range Modules {
+		Reports[Module.ID] = readability.Analyze(Module)
+	}
+
+	return Reports
+}
+
 func (app *App) ListModules(c *gin.Context) {
 
 	// Check if user is authorized.
-	User, err := app.Authorize(c.Request, db.PRIVILEGE_REVIEWER)
+	User, err := app.Authorize(c.Request, db.PERMISSION_READ)
 	if err != nil {
 		c.Redirect(http.StatusFound, "/")
 
@@ -33,18 +46,19 @@ func (app *App) ListModules(c *gin.Context) {
 	var Modules []db.Module
 	app.DB.Where("lower(\"title\") LIKE ?", "a%").Find(&Modules)
 
-	c.HTML(http.StatusOK, "modules-list.html", gin.H{
+	app.RenderHTML(c, http.StatusOK, "modules-list.html", gin.H{
 		"PageTitle":   "Übersicht der Modulbeschreibungen",
 		"User":        User,
 		"FirstLetter": "A",
 		"Modules":     Modules,
+		"Readability": analyzeModules(Modules),
 	})
 }
 
 func (app *App) SearchModules(c *gin.Context) {
 
 	// Check if user is authorized.
-	User, err := app.Authorize(c.Request, db.PRIVILEGE_REVIEWER)
+	User, err := app.Authorize(c.Request, db.PERMISSION_READ)
 	if err != nil {
 		c.Redirect(http.StatusFound, "/")
 
@@ -67,18 +81,19 @@ func (app *App) SearchModules(c *gin.Context) {
 	var Modules []db.Module
 	app.DB.Where("lower(\"title\") LIKE ? OR lower(\"title_english\") LIKE ?", ("%" + Payload.Query + "%"), ("%" + Payload.Query + "%")).Find(&Modules)
 
-	c.HTML(http.StatusOK, "modules-list.html", gin.H{
+	app.RenderHTML(c, http.StatusOK, "modules-list.html", gin.H{
 		"PageTitle":   "Übersicht der Modulbeschreibungen",
 		"User":        User,
 		"FirstLetter": "all",
 		"Modules":     Modules,
+		"Readability": analyzeModules(Modules),
 	})
 }
 
 func (app *App) FilterModulesByLetter(c *gin.Context) {
 
 	// Check if user is authorized.
-	User, err := app.Authorize(c.Request, db.PRIVILEGE_REVIEWER)
+	User, err := app.Authorize(c.Request, db.PERMISSION_READ)
 	if err != nil {
 		c.Redirect(http.StatusFound, "/")
 
@@ -105,11 +120,12 @@ func (app *App) FilterModulesByLetter(c *gin.Context) {
 		app.DB.Where("lower(\"title\") LIKE ?", (Payload.Query + "%")).Find(&Modules)
 	}
 
-	c.HTML(http.StatusOK, "modules-list.html", gin.H{
+	app.RenderHTML(c, http.StatusOK, "modules-list.html", gin.H{
 		"PageTitle":   "Übersicht der Modulbeschreibungen",
 		"User":        User,
 		"FirstLetter": firstLetter,
 		"Modules":     Modules,
+		"Readability": analyzeModules(Modules),
 	})
 }
 
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"net/http"
+
+	"github.com/freitagsrunde/modulist/db"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Constants
+
+const (
+	// How many notifications are listed at once.
+	NOTIFICATIONS_PER_PAGE = 100
+)
+
+// Structs
+
+type NotificationSettingsPayload struct {
+	Digest      bool   `form:"notification-digest"`
+	WatchDigest string `form:"watch-digest" conform:"trim" validate:"required,eq=off|eq=daily|eq=weekly"`
+}
+
+// Functions
+
+// notificationTarget returns the path of the page displaying
+// the feedback a notification refers to.
+func notificationTarget(Notification db.Notification) string {
+	return fmt.Sprintf("/review/module/%d#feedback-%d", Notification.ModuleID, Notification.FeedbackID)
+}
+
+// addUnreadNotifications passes the number of unread
+// notifications of the user in supplied template data,
+// if one is logged in.
+func (app *App) addUnreadNotifications(Data gin.H) {
+
+	if User, ok := Data["User"].(*db.User); ok && (User != nil) {
+		Data["UnreadNotifications"] = db.CountUnreadNotifications(app.DB, User.ID)
+	}
+}
+
+// ListNotifications shows the latest notifications
+// of the logged-in user, unread ones highlighted.
+func (app *App) ListNotifications(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_READ)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/")
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	// List watched modules to allow unwatching them.
+	WatchedModules := []db.Module{}
+	if IDs := db.WatchedModuleIDs(app.DB, *User); len(IDs) > 0 {
+		app.DB.Where("\"id\" IN (?)", IDs).Order("\"title\" asc").Find(&WatchedModules)
+	}
+
+	app.RenderHTML(c, http.StatusOK, "notifications.html", gin.H{
+		"PageTitle":      "Benachrichtigungen",
+		"User":           User,
+		"Notifications":  db.ListNotifications(app.DB, User.ID, NOTIFICATIONS_PER_PAGE),
+		"WatchedModules": WatchedModules,
+	})
+}
+
+// OpenNotification marks a notification as read and
+// leads to the feedback it refers to.
+func (app *App) OpenNotification(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_READ)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/")
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Redirect(http.StatusFound, "/notifications")
+
+		return
+	}
+
+	// Users may only open their own notifications.
+	var Notification db.Notification
+	app.DB.First(&Notification, "\"id\" = ? AND \"user_id\" = ?", id, User.ID)
+
+	if Notification.ID == 0 {
+		c.Redirect(http.StatusFound, "/notifications")
+
+		return
+	}
+
+	app.DB.Model(&Notification).Update("read", true)
+
+	c.Redirect(http.StatusFound, notificationTarget(Notification))
+}
+
+// MarkNotificationsRead marks all notifications
+// of the logged-in user as read.
+func (app *App) MarkNotificationsRead(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_READ)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/")
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	app.DB.Model(&db.Notification{}).Where("\"user_id\" = ? AND \"read\" = ?", User.ID, false).Update("read", true)
+
+	c.Redirect(http.StatusFound, "/notifications")
+}
+
+// UpdateNotificationSettings stores whether the logged-in
+// user wants to receive unread notifications as mail digest.
+func (app *App) UpdateNotificationSettings(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, "")
+	if err != nil {
+		c.Redirect(http.StatusFound, "/")
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	var Payload NotificationSettingsPayload
+
+	if err := c.BindWith(&Payload, binding.FormPost); err != nil {
+
+		app.RenderHTML(c, http.StatusBadRequest, "settings.html", gin.H{
+			"PageTitle":  "Einstellungen",
+			"User":       User,
+			"FatalError": "Gesendete Daten zu Benachrichtigungen konnten nicht verarbeitet werden. Bitte erneut versuchen.",
+		})
+
+		return
+	}
+
+	// Check sent content for validity.
+	if ErrorDesc := app.ConformAndValidate(&Payload); ErrorDesc != nil {
+
+		app.RenderHTML(c, http.StatusBadRequest, "settings.html", gin.H{
+			"PageTitle": "Einstellungen",
+			"User":      User,
+			"Errors":    ErrorDesc,
+		})
+
+		return
+	}
+
+	app.DB.Model(User).Updates(map[string]interface{}{
+		"notification_digest": Payload.Digest,
+		"watch_digest":        Payload.WatchDigest,
+	})
+
+	app.RenderHTML(c, http.StatusOK, "settings.html", gin.H{
+		"PageTitle": "Einstellungen",
+		"User":      User,
+		"Success":   "Einstellungen zu Benachrichtigungen gespeichert.",
+	})
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/freitagsrunde/modulist/db"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+)
+
+// Functions
+
+// OIDCRedirect starts an authorization code flow with
+// PKCE against the configured OpenID Connect provider.
+// State, nonce and code verifier are kept in a short-lived
+// cookie until the provider redirects back to MODULIST.
+func (app *App) OIDCRedirect(c *gin.Context) {
+
+	// Only available if OIDC was configured.
+	if app.OIDC == nil {
+		c.Redirect(http.StatusFound, "/")
+
+		return
+	}
+
+	// Check if user is already logged in.
+	_, err := app.Authorize(c.Request, "")
+	if err == nil {
+		c.Redirect(http.StatusFound, "/modules")
+
+		return
+	}
+
+	state, errState := RandomURLString(24)
+	nonce, errNonce := RandomURLString(24)
+	verifier, errVerifier := RandomURLString(48)
+	if (errState != nil) || (errNonce != nil) || (errVerifier != nil) {
+
+		app.RenderHTML(c, http.StatusInternalServerError, "index.html", gin.H{
+			"PageTitle":   "Willkommen bei MODULIST",
+			"MainTitle":   "Willkommen bei MODULIST",
+			"OIDCEnabled": true,
+			"FatalError":  "Auf dem Server ist ein Fehler aufgetreten. Erneut versuchen oder Admin kontaktieren.",
+		})
+
+		return
+	}
+
+	// Remember flow parameters for ten minutes. The provider redirects
+	// back cross-site, so a strict cookie would not be sent along.
+	app.SetCookie(c, "OIDCState", strings.Join([]string{state, nonce, verifier}, "."), 600, "/login/oidc", http.SameSiteLaxMode)
+
+	authURL := app.OIDC.OAuth2.AuthCodeURL(state,
+		oauth2.SetAuthURLParam("nonce", nonce),
+		oauth2.SetAuthURLParam("code_challenge", PKCEChallenge(verifier)),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"))
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback is called by the OpenID Connect provider
+// after the user authenticated there. It exchanges the
+// authorization code for an ID token, maps the contained
+// mail claim to a MODULIST account and creates a session.
+func (app *App) OIDCCallback(c *gin.Context) {
+
+	// Only available if OIDC was configured.
+	if app.OIDC == nil {
+		c.Redirect(http.StatusFound, "/")
+
+		return
+	}
+
+	// Every error below is reported to the user the same way.
+	failLogin := func(status int, reason string) {
+
+		app.RenderHTML(c, status, "index.html", gin.H{
+			"PageTitle":   "Willkommen bei MODULIST",
+			"MainTitle":   "Willkommen bei MODULIST",
+			"OIDCEnabled": true,
+			"FatalError":  reason,
+		})
+	}
+
+	// Load and immediately delete flow parameters.
+	cookie, err := c.Request.Cookie("OIDCState")
+	if err != nil {
+		failLogin(http.StatusBadRequest, "Anmeldung abgelaufen. Bitte erneut versuchen.")
+
+		return
+	}
+	app.SetCookie(c, "OIDCState", "", -1, "/login/oidc", http.SameSiteLaxMode)
+
+	flow := strings.Split(cookie.Value, ".")
+	if len(flow) != 3 {
+		failLogin(http.StatusBadRequest, "Anmeldung abgelaufen. Bitte erneut versuchen.")
+
+		return
+	}
+	state, nonce, verifier := flow[0], flow[1], flow[2]
+
+	// Provider might report an error, e.g. if the user denied access.
+	if providerErr := c.Query("error"); providerErr != "" {
+		log.Printf("[OIDCCallback] Provider returned error: %s.\n", providerErr)
+		failLogin(http.StatusBadRequest, "Anmeldung beim Identitätsanbieter fehlgeschlagen.")
+
+		return
+	}
+
+	// Compare state parameter to the one we sent out.
+	if subtle.ConstantTimeCompare([]byte(c.Query("state")), []byte(state)) != 1 {
+		failLogin(http.StatusBadRequest, "Anmeldung abgelaufen. Bitte erneut versuchen.")
+
+		return
+	}
+
+	// Exchange authorization code for tokens, proving possession of the verifier.
+	ctx := context.Background()
+	token, err := app.OIDC.OAuth2.Exchange(ctx, c.Query("code"), oauth2.SetAuthURLParam("code_verifier", verifier))
+	if err != nil {
+		log.Printf("[OIDCCallback] Exchanging authorization code failed: %s.\n", err)
+		failLogin(http.StatusBadRequest, "Anmeldung beim Identitätsanbieter fehlgeschlagen.")
+
+		return
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		log.Println("[OIDCCallback] Token response did not contain an ID token.")
+		failLogin(http.StatusBadRequest, "Anmeldung beim Identitätsanbieter fehlgeschlagen.")
+
+		return
+	}
+
+	// Verify signature, issuer, audience and expiry of ID token.
+	idToken, err := app.OIDC.Verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		log.Printf("[OIDCCallback] Verifying ID token failed: %s.\n", err)
+		failLogin(http.StatusBadRequest, "Anmeldung beim Identitätsanbieter fehlgeschlagen.")
+
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(nonce)) != 1 {
+		log.Println("[OIDCCallback] Nonce in ID token did not match.")
+		failLogin(http.StatusBadRequest, "Anmeldung beim Identitätsanbieter fehlgeschlagen.")
+
+		return
+	}
+
+	var Claims OIDCClaims
+	if err := idToken.Claims(&Claims); err != nil {
+		log.Printf("[OIDCCallback] Parsing claims of ID token failed: %s.\n", err)
+		failLogin(http.StatusBadRequest, "Anmeldung beim Identitätsanbieter fehlgeschlagen.")
+
+		return
+	}
+
+	// We rely on the mail address to identify users.
+	// Only accept addresses the provider has verified.
+	Claims.Mail = strings.ToLower(strings.TrimSpace(Claims.Mail))
+	if (Claims.Mail == "") || ((Claims.MailVerified != nil) && !*Claims.MailVerified) {
+		failLogin(http.StatusForbidden, "Der Identitätsanbieter hat keine bestätigte Mail-Adresse übermittelt.")
+
+		return
+	}
+
+	// Try to find an existing account for that mail address.
+	var User db.User
+	app.DB.Preload("Roles").First(&User, "lower(\"mail\") = ?", Claims.Mail)
+
+	if User.ID == "" {
+
+		// Unknown users are only let in if auto provisioning is enabled.
+		if !app.OIDC.AutoProvision {
+			failLogin(http.StatusForbidden, "Für diese Mail-Adresse existiert kein Account in MODULIST.")
+
+			return
+		}
+
+		// Fall back to the preferred username if no names were supplied.
+		if Claims.FirstName == "" {
+			Claims.FirstName = Claims.PreferredName
+		}
+
+		if Claims.LastName == "" {
+			Claims.LastName = Claims.PreferredName
+		}
+
+		NewUser, err := app.ProvisionUser(Claims.FirstName, Claims.LastName, Claims.Mail, []string{db.ROLE_REVIEWER})
+		if err != nil {
+			failLogin(http.StatusInternalServerError, "Auf dem Server ist ein Fehler aufgetreten. Erneut versuchen oder Admin kontaktieren.")
+
+			return
+		}
+
+		User = *NewUser
+	}
+
+	// Deactivated accounts stay deactivated.
+	if !User.Enabled {
+		failLogin(http.StatusForbidden, "Dieser Account ist deaktiviert.")
+
+		return
+	}
+
+	// Create a JWT and store it as a cookie.
+	app.CreateSession(c, User)
+
+	// Redirect to first authorized page. This request was started
+	// by the provider, thus a plain redirect would not carry the
+	// same-site session cookie. Navigate from within our site instead.
+	app.RenderHTML(c, http.StatusOK, "redirect.html", gin.H{
+		"PageTitle": "Willkommen bei MODULIST",
+		"Target":    LandingPage(User),
+	})
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/coreos/go-oidc"
+	"github.com/freitagsrunde/modulist/db"
+	"golang.org/x/oauth2"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// Structs
+
+// testProvider is a minimal OpenID Connect provider serving
+// discovery document, signing keys and token endpoint. It
+// hands out one authorization code per started login.
+type testProvider struct {
+	Server *httptest.Server
+	Key    *rsa.PrivateKey
+
+	mutex     sync.Mutex
+	Challenge string
+	Nonce     string
+	Mail      string
+}
+
+// Functions
+
+func newTestProvider(t *testing.T) *testProvider {
+
+	Key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating signing key failed: %s", err)
+	}
+
+	provider := &testProvider{Key: Key}
+
+	Mux := http.NewServeMux()
+
+	Mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                provider.Server.URL,
+			"authorization_endpoint":                provider.Server.URL + "/auth",
+			"token_endpoint":                        provider.Server.URL + "/token",
+			"jwks_uri":                              provider.Server.URL + "/keys",
+			"response_types_supported":              []string{"code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+
+	Mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{
+			Keys: []jose.JSONWebKey{{Key: &Key.PublicKey, KeyID: "test", Algorithm: "RS256", Use: "sig"}},
+		})
+	})
+
+	Mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+
+		provider.mutex.Lock()
+		defer provider.mutex.Unlock()
+
+		// Only the verifier belonging to the challenge redeems the code.
+		if (r.PostFormValue("code") != "test-code") || (PKCEChallenge(r.PostFormValue("code_verifier")) != provider.Challenge) {
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "test-access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     provider.idToken(t),
+		})
+	})
+
+	provider.Server = httptest.NewServer(Mux)
+	t.Cleanup(provider.Server.Close)
+
+	return provider
+}
+
+// idToken signs an ID token for the configured mail
+// address carrying the configured nonce.
+func (provider *testProvider) idToken(t *testing.T) string {
+
+	Signer, err := jose.NewSigner(jose.SigningKey{
+		Algorithm: jose.RS256,
+		Key:       jose.JSONWebKey{Key: provider.Key, KeyID: "test"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("creating signer failed: %s", err)
+	}
+
+	Claims, _ := json.Marshal(map[string]interface{}{
+		"iss":            provider.Server.URL,
+		"sub":            "test-subject",
+		"aud":            "modulist",
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"nonce":          provider.Nonce,
+		"email":          provider.Mail,
+		"email_verified": true,
+		"given_name":     "Erika",
+		"family_name":    "Mustermann",
+	})
+
+	Signed, err := Signer.Sign(Claims)
+	if err != nil {
+		t.Fatalf("signing ID token failed: %s", err)
+	}
+
+	Token, err := Signed.CompactSerialize()
+	if err != nil {
+		t.Fatalf("serializing ID token failed: %s", err)
+	}
+
+	return Token
+}
+
+// newOIDCTestApp returns a test app logging in via
+// supplied provider.
+func newOIDCTestApp(t *testing.T, provider *testProvider, AutoProvision bool) *App {
+
+	app := newTestApp(t)
+
+	Provider, err := oidc.NewProvider(context.Background(), provider.Server.URL)
+	if err != nil {
+		t.Fatalf("loading discovery document failed: %s", err)
+	}
+
+	app.OIDC = &OIDCLogin{
+		Provider: Provider,
+		Verifier: Provider.Verifier(&oidc.Config{ClientID: "modulist"}),
+		OAuth2: oauth2.Config{
+			ClientID:     "modulist",
+			ClientSecret: "secret",
+			RedirectURL:  "http://modulist.test/login/oidc/callback",
+			Endpoint:     Provider.Endpoint(),
+			Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+		},
+		AutoProvision: AutoProvision,
+	}
+
+	return app
+}
+
+// startLogin requests the redirect to the provider and lets the
+// provider remember challenge and nonce, as if the user logged in
+// there. It returns the state parameter and the flow cookie.
+func startLogin(t *testing.T, app *App, provider *testProvider, Mail string) (string, *http.Cookie) {
+
+	Recorder := httptest.NewRecorder()
+	app.Router.ServeHTTP(Recorder, httptest.NewRequest(http.MethodGet, "/login/oidc", nil))
+
+	if Recorder.Code != http.StatusFound {
+		t.Fatalf("expected redirect to provider, got status %d", Recorder.Code)
+	}
+
+	Location, err := url.Parse(Recorder.Header().Get("Location"))
+	if err != nil || !strings.HasPrefix(Location.String(), provider.Server.URL+"/auth") {
+		t.Fatalf("expected redirect to authorization endpoint, got %q", Recorder.Header().Get("Location"))
+	}
+
+	Query := Location.Query()
+	if Query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("expected S256 code challenge, got %q", Query.Get("code_challenge_method"))
+	}
+
+	provider.mutex.Lock()
+	provider.Challenge = Query.Get("code_challenge")
+	provider.Nonce = Query.Get("nonce")
+	provider.Mail = Mail
+	provider.mutex.Unlock()
+
+	for _, Cookie := range Recorder.Result().Cookies() {
+
+		if Cookie.Name == "OIDCState" {
+			return Query.Get("state"), Cookie
+		}
+	}
+
+	t.Fatal("expected flow parameters to be stored in a cookie")
+
+	return "", nil
+}
+
+// finishLogin calls the callback the way the provider
+// redirects back after the user authenticated.
+func finishLogin(app *App, State string, Cookie *http.Cookie) *httptest.ResponseRecorder {
+
+	Request := httptest.NewRequest(http.MethodGet, "/login/oidc/callback?code=test-code&state="+url.QueryEscape(State), nil)
+	Request.AddCookie(Cookie)
+
+	Recorder := httptest.NewRecorder()
+	app.Router.ServeHTTP(Recorder, Request)
+
+	return Recorder
+}
+
+func TestOIDCCallbackLogsInExistingUser(t *testing.T) {
+
+	provider := newTestProvider(t)
+	app := newOIDCTestApp(t, provider, false)
+	createTestUser(t, app, "owner@example.org", "unused", true, db.ROLE_OWNER)
+
+	State, Cookie := startLogin(t, app, provider, "Owner@Example.org")
+	Recorder := finishLogin(app, State, Cookie)
+
+	if Recorder.Code != http.StatusOK {
+		t.Fatalf("expected successful login, got status %d", Recorder.Code)
+	}
+
+	if sessionCookie(Recorder.Result()) == nil {
+		t.Fatal("expected a session to be created")
+	}
+
+	// Roles of the account decide where the user lands.
+	if !strings.Contains(Recorder.Body.String(), "url=/owner") {
+		t.Errorf("expected redirect to owner page, got %s", Recorder.Body.String())
+	}
+}
+
+func TestOIDCCallbackProvisionsUnknownUser(t *testing.T) {
+
+	provider := newTestProvider(t)
+	app := newOIDCTestApp(t, provider, true)
+
+	State, Cookie := startLogin(t, app, provider, "new@example.org")
+	Recorder := finishLogin(app, State, Cookie)
+
+	if (Recorder.Code != http.StatusOK) || (sessionCookie(Recorder.Result()) == nil) {
+		t.Fatalf("expected successful login, got status %d", Recorder.Code)
+	}
+
+	User := loadTestUser(app, "new@example.org")
+	if !User.Enabled || !User.HasRole(db.ROLE_REVIEWER) || (User.FirstName != "Erika") || (User.LastName != "Mustermann") {
+		t.Errorf("expected enabled reviewer account named after the claims, got %+v", User)
+	}
+
+	if !strings.Contains(Recorder.Body.String(), "url=/modules") {
+		t.Errorf("expected redirect to module list, got %s", Recorder.Body.String())
+	}
+}
+
+func TestOIDCCallbackRejectsUnknownUserWithoutProvisioning(t *testing.T) {
+
+	provider := newTestProvider(t)
+	app := newOIDCTestApp(t, provider, false)
+
+	State, Cookie := startLogin(t, app, provider, "stranger@example.org")
+	Recorder := finishLogin(app, State, Cookie)
+
+	if (Recorder.Code != http.StatusForbidden) || (sessionCookie(Recorder.Result()) != nil) {
+		t.Fatalf("expected login to be refused, got status %d", Recorder.Code)
+	}
+
+	if User := loadTestUser(app, "stranger@example.org"); User.ID != "" {
+		t.Errorf("expected no account to be created, got %+v", User)
+	}
+}
+
+func TestOIDCCallbackRejectsStateMismatch(t *testing.T) {
+
+	provider := newTestProvider(t)
+	app := newOIDCTestApp(t, provider, true)
+
+	_, Cookie := startLogin(t, app, provider, "new@example.org")
+	Recorder := finishLogin(app, "forged-state", Cookie)
+
+	if (Recorder.Code != http.StatusBadRequest) || (sessionCookie(Recorder.Result()) != nil) {
+		t.Fatalf("expected login to be refused, got status %d", Recorder.Code)
+	}
+}
+
+func TestOIDCCallbackRejectsPKCEMismatch(t *testing.T) {
+
+	provider := newTestProvider(t)
+	app := newOIDCTestApp(t, provider, true)
+
+	State, Cookie := startLogin(t, app, provider, "new@example.org")
+
+	// Swap the code verifier for one not matching the challenge.
+	Flow := strings.Split(Cookie.Value, ".")
+	Forged, _ := RandomURLString(48)
+	Cookie.Value = strings.Join([]string{Flow[0], Flow[1], Forged}, ".")
+
+	Recorder := finishLogin(app, State, Cookie)
+
+	if (Recorder.Code != http.StatusBadRequest) || (sessionCookie(Recorder.Result()) != nil) {
+		t.Fatalf("expected login to be refused, got status %d", Recorder.Code)
+	}
+}
+
+func TestOIDCCallbackRejectsNonceMismatch(t *testing.T) {
+
+	provider := newTestProvider(t)
+	app := newOIDCTestApp(t, provider, true)
+
+	State, Cookie := startLogin(t, app, provider, "new@example.org")
+
+	// Provider issues a token belonging to another login.
+	provider.mutex.Lock()
+	provider.Nonce = "replayed-nonce"
+	provider.mutex.Unlock()
+
+	Recorder := finishLogin(app, State, Cookie)
+
+	if (Recorder.Code != http.StatusBadRequest) || (sessionCookie(Recorder.Result()) != nil) {
+		t.Fatalf("expected login to be refused, got status %d", Recorder.Code)
+	}
+
+	if User := loadTestUser(app, "new@example.org"); User.ID != "" {
+		t.Errorf("expected no account to be created, got %+v", User)
+	}
+}
@@ -0,0 +1,553 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"crypto/rand"
+	"net/http"
+
+	"github.com/freitagsrunde/modulist/db"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/satori/go.uuid"
+)
+
+// Constants
+
+const (
+	// Minutes a login link for module owners stays valid.
+	LOGIN_LINK_VALID_FOR = 30
+
+	// Subject and body of mails containing a login link.
+	LOGIN_LINK_MAIL_SUBJECT = "Ihr Login-Link für MODULIST"
+	LOGIN_LINK_MAIL_BODY    = `Hallo,
+
+über folgenden Link können Sie sich bei MODULIST anmelden und das
+Feedback zu den Modulen Ihres Fachgebiets einsehen und beantworten:
+
+%s
+
+Der Link ist %d Minuten gültig und kann nur einmal verwendet werden.
+Falls Sie keinen Login-Link angefordert haben, ignorieren Sie diese Mail.
+
+Ihre Freitagsrunde
+`
+)
+
+// Structs
+
+type RequestLoginLinkPayload struct {
+	Mail string `form:"login-mail" conform:"trim,email" validate:"required,email"`
+}
+
+type LoginLinkPayload struct {
+	SecretToken string `conform:"trim" validate:"required,len=72,alphanum"`
+}
+
+type ReplyToFeedbackPayload struct {
+	Comment string `form:"comment" conform:"trim" validate:"required"`
+}
+
+type FeedbackCount struct {
+	ModuleID int
+	Count    int
+}
+
+// Functions
+
+// OwnerFeedbackFilter selects the comments module owners
+// get to see. Comments rejected by moderators are left out.
+func OwnerFeedbackFilter() db.FeedbackFilter {
+
+	return db.FeedbackFilter{
+		Statuses: []string{db.STATUS_OPEN, db.STATUS_ACCEPTED, db.STATUS_RESOLVED},
+	}
+}
+
+// mayUseLoginLink decides whether a login link may be
+// issued for, respectively used by, supplied mail address.
+// Links are handed out to enabled accounts holding nothing
+// but the owner role and, for addresses listed as contact of
+// at least one module, to owners without an account yet. A
+// login link thus never grants more than a password would.
+func (app *App) mayUseLoginLink(Mail string) bool {
+
+	var User db.User
+	app.DB.Preload("Roles").First(&User, "lower(\"mail\") = lower(?)", Mail)
+
+	if User.ID == "" {
+		return len(db.OwnedModules(app.DB, db.User{Mail: Mail})) > 0
+	}
+
+	// Disabled accounts have to be activated by an admin,
+	// a login link must not undo a deactivation.
+	if !User.Enabled {
+		return false
+	}
+
+	return User.OnlyOwnsModules()
+}
+
+// LoginLinkView renders the page on which module
+// owners can request a login link via mail.
+func (app *App) LoginLinkView(c *gin.Context) {
+
+	app.RenderHTML(c, http.StatusOK, "login-link.html", gin.H{
+		"PageTitle": "Login für Fachgebiete",
+		"MainTitle": "Login für Fachgebiete",
+	})
+}
+
+// RequestLoginLink sends a login link to the supplied
+// mail address, if it belongs to a module owner. The
+// response does not tell whether a mail was sent, in
+// order to not disclose which addresses are known.
+func (app *App) RequestLoginLink(c *gin.Context) {
+
+	var Payload RequestLoginLinkPayload
+
+	err := c.BindWith(&Payload, binding.FormPost)
+	if err != nil {
+
+		app.RenderHTML(c, http.StatusBadRequest, "login-link.html", gin.H{
+			"PageTitle":  "Login für Fachgebiete",
+			"MainTitle":  "Login für Fachgebiete",
+			"FatalError": "Gesendete Daten konnten nicht verarbeitet werden. Bitte erneut versuchen.",
+		})
+
+		return
+	}
+
+	// Check sent content for validity.
+	ErrorDesc := app.ConformAndValidate(&Payload)
+	if ErrorDesc != nil {
+
+		// If payload did not pass, report errors to user.
+		app.RenderHTML(c, http.StatusBadRequest, "login-link.html", gin.H{
+			"PageTitle": "Login für Fachgebiete",
+			"MainTitle": "Login für Fachgebiete",
+			"Errors":    ErrorDesc,
+		})
+
+		return
+	}
+
+	// Clean up links nobody used.
+	app.DB.Delete(db.LoginLink{}, "\"expires\" < ?", time.Now())
+
+	if app.mayUseLoginLink(Payload.Mail) {
+
+		// Generate secret token of new login link.
+		randomBytes := make([]byte, 36)
+		_, err = rand.Read(randomBytes)
+		if err != nil {
+
+			log.Printf("[RequestLoginLink] Generating random bytes for login link went wrong: %s.\n", err.Error())
+
+			app.RenderHTML(c, http.StatusInternalServerError, "login-link.html", gin.H{
+				"PageTitle":  "Login für Fachgebiete",
+				"MainTitle":  "Login für Fachgebiete",
+				"FatalError": "Auf dem Server ist ein Fehler aufgetreten. Erneut versuchen oder Admin kontaktieren.",
+			})
+
+			return
+		}
+
+		LoginLink := db.LoginLink{
+			ID:          fmt.Sprintf("%s", uuid.NewV4()),
+			Mail:        strings.ToLower(Payload.Mail),
+			SecretToken: fmt.Sprintf("%x", randomBytes),
+			Expires:     time.Now().Add(LOGIN_LINK_VALID_FOR * time.Minute),
+		}
+
+		// Save login link element to database.
+		app.DB.Create(&LoginLink)
+
+		body := fmt.Sprintf(LOGIN_LINK_MAIL_BODY, fmt.Sprintf("%s/login/link/%s", app.BaseURL, LoginLink.SecretToken), LOGIN_LINK_VALID_FOR)
+
+		if err := app.Mailer.Send([]string{LoginLink.Mail}, LOGIN_LINK_MAIL_SUBJECT, body); err != nil {
+
+			log.Printf("[RequestLoginLink] Sending login link to '%s' failed: %s.\n", LoginLink.Mail, err.Error())
+
+			app.RenderHTML(c, http.StatusInternalServerError, "login-link.html", gin.H{
+				"PageTitle":  "Login für Fachgebiete",
+				"MainTitle":  "Login für Fachgebiete",
+				"FatalError": "Die Mail konnte nicht versandt werden. Erneut versuchen oder Admin kontaktieren.",
+			})
+
+			return
+		}
+	}
+
+	app.RenderHTML(c, http.StatusOK, "login-link.html", gin.H{
+		"PageTitle": "Login für Fachgebiete",
+		"MainTitle": "Login für Fachgebiete",
+		"Success":   fmt.Sprintf("Falls zu dieser Adresse Module hinterlegt sind, wurde ein Login-Link versandt. Er ist %d Minuten gültig.", LOGIN_LINK_VALID_FOR),
+	})
+}
+
+// ConfirmLoginLink asks the owner following a login link
+// to confirm the login. Only the confirmation uses up the
+// link, thus programs scanning links inside mails do not.
+func (app *App) ConfirmLoginLink(c *gin.Context) {
+
+	// Extract supposed secret token from URL.
+	Payload := LoginLinkPayload{
+		SecretToken: c.Param("secretToken"),
+	}
+
+	// Check secret token for conformity and validity.
+	if errs := app.ConformAndValidate(&Payload); errs != nil {
+		c.Redirect(http.StatusFound, "/login/link")
+
+		return
+	}
+
+	// Attempt to find a still valid login link with this token.
+	var LoginLink db.LoginLink
+	app.DB.First(&LoginLink, "\"secret_token\" = ? AND \"expires\" > ?", Payload.SecretToken, time.Now())
+
+	if LoginLink.ID == "" {
+
+		app.RenderHTML(c, http.StatusNotFound, "login-link.html", gin.H{
+			"PageTitle":  "Login für Fachgebiete",
+			"MainTitle":  "Login für Fachgebiete",
+			"FatalError": "Dieser Login-Link ist abgelaufen oder wurde bereits verwendet. Bitte einen neuen anfordern.",
+		})
+
+		return
+	}
+
+	app.RenderHTML(c, http.StatusOK, "login-link.html", gin.H{
+		"PageTitle":   "Login für Fachgebiete",
+		"MainTitle":   "Login für Fachgebiete",
+		"SecretToken": Payload.SecretToken,
+		"Mail":        LoginLink.Mail,
+	})
+}
+
+// UseLoginLink uses up a login link and logs in the
+// module owner it was sent to. Owners without an account
+// receive one holding the owner role.
+func (app *App) UseLoginLink(c *gin.Context) {
+
+	// Extract supposed secret token from URL.
+	Payload := LoginLinkPayload{
+		SecretToken: c.Param("secretToken"),
+	}
+
+	// Check secret token for conformity and validity.
+	if errs := app.ConformAndValidate(&Payload); errs != nil {
+		c.Redirect(http.StatusFound, "/login/link")
+
+		return
+	}
+
+	var LoginLink db.LoginLink
+	app.DB.First(&LoginLink, "\"secret_token\" = ?", Payload.SecretToken)
+
+	// Each link can only be used once.
+	if LoginLink.ID != "" {
+		app.DB.Delete(&LoginLink)
+	}
+
+	// Permissions might have changed since the link was sent.
+	if (LoginLink.ID == "") || time.Now().After(LoginLink.Expires) || !app.mayUseLoginLink(LoginLink.Mail) {
+
+		app.RenderHTML(c, http.StatusForbidden, "login-link.html", gin.H{
+			"PageTitle":  "Login für Fachgebiete",
+			"MainTitle":  "Login für Fachgebiete",
+			"FatalError": "Dieser Login-Link ist abgelaufen oder wurde bereits verwendet. Bitte einen neuen anfordern.",
+		})
+
+		return
+	}
+
+	var User db.User
+	app.DB.Preload("Roles").First(&User, "lower(\"mail\") = lower(?)", LoginLink.Mail)
+
+	if User.ID == "" {
+
+		// Name the account after the office of the owned modules.
+		lastName := LoginLink.Mail
+		if Modules := db.OwnedModules(app.DB, db.User{Mail: LoginLink.Mail}); (len(Modules) > 0) && Modules[0].AdministrationOffice.Valid {
+			lastName = Modules[0].AdministrationOffice.String
+		}
+
+		NewUser, err := app.ProvisionUser("Fachgebiet", lastName, LoginLink.Mail, []string{db.ROLE_OWNER})
+		if err != nil {
+
+			app.RenderHTML(c, http.StatusInternalServerError, "login-link.html", gin.H{
+				"PageTitle":  "Login für Fachgebiete",
+				"MainTitle":  "Login für Fachgebiete",
+				"FatalError": "Auf dem Server ist ein Fehler aufgetreten. Erneut versuchen oder Admin kontaktieren.",
+			})
+
+			return
+		}
+
+		User = *NewUser
+	} else if !User.MailVerified {
+
+		// Receiving the link proved possession of the mail address.
+		app.DB.Model(&User).Update("mail_verified", true)
+	}
+
+	// Create a JWT and store it as a cookie.
+	app.CreateSession(c, User)
+
+	c.Redirect(http.StatusFound, "/owner")
+}
+
+// ListOwnedModules shows a module owner all
+// owned modules and their amount of feedback.
+func (app *App) ListOwnedModules(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_OWN_MODULES)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/")
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	Modules := db.OwnedModules(app.DB, *User)
+
+	ModuleIDs := make([]int, len(Modules))
+	for i, Module := range Modules {
+		ModuleIDs[i] = Module.ID
+	}
+
+	// Count top-level feedback per module.
+	var Counts []FeedbackCount
+	FeedbackCounts := make(map[int]int)
+
+	if len(ModuleIDs) > 0 {
+
+		app.DB.Model(&db.Feedback{}).Select("\"module_id\", count(*) AS \"count\"").
+			Where("\"module_id\" IN (?) AND \"parent_id\" = ?", ModuleIDs, 0).
+			Group("\"module_id\"").Scan(&Counts)
+	}
+
+	for _, Count := range Counts {
+		FeedbackCounts[Count.ModuleID] = Count.Count
+	}
+
+	app.RenderHTML(c, http.StatusOK, "owner-modules.html", gin.H{
+		"PageTitle":      "Meine Module",
+		"User":           User,
+		"Modules":        Modules,
+		"FeedbackCounts": FeedbackCounts,
+	})
+}
+
+// loadOwnedModule authorizes a module owner and loads the
+// owned module addressed in the URL. If anything does not
+// check out, the request is answered and false returned.
+func (app *App) loadOwnedModule(c *gin.Context) (*db.User, db.Module, bool) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_OWN_MODULES)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/")
+
+		return nil, db.Module{}, false
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	// Extract ID of module from URL.
+	id, err := strconv.Atoi(c.Param("moduleID"))
+	if err != nil {
+		c.Redirect(http.StatusFound, "/owner")
+
+		return nil, db.Module{}, false
+	}
+
+	Payload := ReviewModulePayload{ID: id}
+
+	// Check supplied ID for conformity and validity.
+	if errs := app.ConformAndValidate(&Payload); errs != nil {
+		c.Redirect(http.StatusFound, "/owner")
+
+		return nil, db.Module{}, false
+	}
+
+	// Other modules do not exist for owners.
+	Module := db.FindOwnedModule(app.DB, *User, Payload.ID)
+	if Module.URL == "" {
+		c.Redirect(http.StatusFound, "/owner")
+
+		return nil, db.Module{}, false
+	}
+
+	return User, Module, true
+}
+
+// renderOwnerModule displays all feedback on an owned
+// module together with the replies to each comment.
+// Comments rejected by moderators are left out.
+func (app *App) renderOwnerModule(c *gin.Context, Code int, User *db.User, Module db.Module, Data gin.H) {
+
+	Filter := OwnerFeedbackFilter()
+
+	Data["PageTitle"] = fmt.Sprintf("Feedback zu Modul #%d", Module.ModuleID)
+	Data["User"] = User
+	Data["Module"] = Module
+	Threads := db.ListThreads(app.DB, Module.ID)
+	db.LoadVotes(app.DB, Threads, User.ID)
+	db.SortBySupport(Threads)
+	Threads = db.FilterThreads(Threads, Filter)
+	ResolveAnchors(Module, Threads)
+	ResolveSuggestions(Module, Threads)
+	renderComments(Threads)
+
+	Data["Feedback"] = Threads
+
+	// Feedback on courses applies to all modules including them.
+	app.DB.Model(&Module).Related(&Module.Courses, "Courses")
+
+	CourseFeedback := db.ListCourseFeedback(app.DB, Module.CourseIDs()...)
+	renderCourseComments(CourseFeedback)
+
+	Data["CourseFeedback"] = CourseFeedback
+	Data["CategoryTitles"] = db.CategoryTitles()
+	Data["FieldTitles"] = db.FieldTitles()
+	Data["SeverityTitles"] = db.SeverityTitles()
+	Data["StatusTitles"] = db.StatusTitles()
+	Data["Watching"] = db.IsWatching(app.DB, *User, Module.ID)
+
+	app.RenderHTML(c, Code, "owner-feedback.html", Data)
+}
+
+// OwnerModuleFeedback shows a module owner the
+// feedback on one of the owned modules.
+func (app *App) OwnerModuleFeedback(c *gin.Context) {
+
+	User, Module, ok := app.loadOwnedModule(c)
+	if !ok {
+		return
+	}
+
+	app.renderOwnerModule(c, http.StatusOK, User, Module, gin.H{})
+}
+
+// ReplyToFeedback stores the reply of a module
+// owner to a comment on one of the owned modules.
+func (app *App) ReplyToFeedback(c *gin.Context) {
+
+	User, Module, ok := app.loadOwnedModule(c)
+	if !ok {
+		return
+	}
+
+	// Extract ID of comment to reply to from URL.
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Redirect(http.StatusFound, fmt.Sprintf("/owner/module/%d", Module.ID))
+
+		return
+	}
+
+	// Replies are only possible to top-level comments of this
+	// module, which owners can see, i.e. not rejected ones.
+	var Parent db.Feedback
+	app.DB.First(&Parent, "\"id\" = ? AND \"module_id\" = ? AND \"parent_id\" = ? AND \"status\" <> ?", id, Module.ID, 0, db.STATUS_REJECTED)
+
+	if Parent.ID == 0 {
+
+		app.renderOwnerModule(c, http.StatusBadRequest, User, Module, gin.H{
+			"FatalError": "Der Kommentar, auf den geantwortet werden sollte, existiert nicht.",
+		})
+
+		return
+	}
+
+	var Payload ReplyToFeedbackPayload
+
+	err = c.BindWith(&Payload, binding.FormPost)
+	if err != nil {
+
+		app.renderOwnerModule(c, http.StatusBadRequest, User, Module, gin.H{
+			"FatalError": "Gesendete Antwort konnte nicht verarbeitet werden. Bitte erneut versuchen.",
+		})
+
+		return
+	}
+
+	// Check sent content for validity.
+	ErrorDesc := app.ConformAndValidate(&Payload)
+	if ErrorDesc != nil {
+
+		app.renderOwnerModule(c, http.StatusBadRequest, User, Module, gin.H{
+			"Errors": ErrorDesc,
+		})
+
+		return
+	}
+
+	// Save reply to database.
+	Reply := db.Feedback{
+		ModuleID: Module.ID,
+		UserID:   User.ID,
+		Category: Parent.Category,
+		Comment:  Payload.Comment,
+		ParentID: Parent.ID,
+	}
+	app.DB.Create(&Reply)
+	app.NotifyMentions(*User, Reply)
+	app.RecordEvent(*User, Reply, db.EVENT_REPLY, "")
+	app.publishFeedback(FEEDBACK_CREATED, *User, Reply)
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/owner/module/%d#feedback-%d", Module.ID, Parent.ID))
+}
+
+// ResolveFeedback lets a module owner mark a comment on
+// one of the owned modules as resolved, or open it again.
+func (app *App) ResolveFeedback(c *gin.Context) {
+
+	User, Module, ok := app.loadOwnedModule(c)
+	if !ok {
+		return
+	}
+
+	// Extract ID of comment from URL.
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Redirect(http.StatusFound, fmt.Sprintf("/owner/module/%d", Module.ID))
+
+		return
+	}
+
+	// Rejected comments are not visible to owners.
+	var Feedback db.Feedback
+	app.DB.First(&Feedback, "\"id\" = ? AND \"module_id\" = ? AND \"parent_id\" = ? AND \"status\" <> ?", id, Module.ID, 0, db.STATUS_REJECTED)
+
+	if Feedback.ID == 0 {
+
+		app.renderOwnerModule(c, http.StatusBadRequest, User, Module, gin.H{
+			"FatalError": "Der Kommentar existiert nicht.",
+		})
+
+		return
+	}
+
+	Status := db.STATUS_RESOLVED
+	if Feedback.Status == db.STATUS_RESOLVED {
+		Status = db.STATUS_OPEN
+	}
+
+	app.DB.Model(&Feedback).Update("status", Status)
+	app.RecordEvent(*User, Feedback, db.EVENT_STATUS, Status)
+	app.publishFeedback(FEEDBACK_EDITED, *User, Feedback)
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/owner/module/%d#feedback-%d", Module.ID, Feedback.ID))
+}
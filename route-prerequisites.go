@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"net/http"
+
+	"github.com/freitagsrunde/modulist/db"
+	"github.com/freitagsrunde/modulist/graph"
+	"github.com/gin-gonic/gin"
+)
+
+// Functions
+
+// respondGraph sends supplied graph as SVG image or as
+// DOT file, depending on the format named in the URL.
+func respondGraph(c *gin.Context, Graph graph.Graph, HighlightID int, Name string) {
+
+	switch c.Param("format") {
+
+	case "svg":
+		c.Data(http.StatusOK, "image/svg+xml; charset=utf-8", []byte(Graph.SVG(HighlightID, "/review/module/%d")))
+
+	case "dot":
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.dot\"", Name))
+		c.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", []byte(Graph.DOT()))
+
+	default:
+		c.String(http.StatusNotFound, "Unknown format.")
+	}
+}
+
+// ModulePrerequisites draws the modules a module requires,
+// directly or transitively, and the modules requiring it.
+func (app *App) ModulePrerequisites(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_READ)
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Not authorized.")
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	// Extract ID of module from URL.
+	id, err := strconv.Atoi(c.Param("moduleID"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Malformed ID.")
+
+		return
+	}
+
+	Prerequisites := app.LoadPrerequisites()
+
+	Module, found := Prerequisites.Modules[id]
+	if !found {
+		c.String(http.StatusNotFound, "Module does not exist.")
+
+		return
+	}
+
+	respondGraph(c, Prerequisites.Graph.Neighbourhood(id), id, fmt.Sprintf("voraussetzungen-%d", Module.ModuleID))
+}
+
+// renderPrerequisites lists cycles and references to
+// modules missing from the catalogue.
+func (app *App) renderPrerequisites(c *gin.Context, Code int, User *db.User, Data gin.H) {
+
+	Prerequisites := app.LoadPrerequisites()
+
+	Data["PageTitle"] = "Admin - Voraussetzungen"
+	Data["User"] = User
+	Data["Requirements"] = len(Prerequisites.Graph.Edges)
+	Data["Cycles"] = Prerequisites.Cycles
+	Data["Missing"] = Prerequisites.Missing
+
+	app.RenderHTML(c, Code, "admin-prerequisites.html", Data)
+}
+
+// ListPrerequisites shows the state of the prerequisite
+// graph of the whole catalogue.
+func (app *App) ListPrerequisites(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_MODERATE_FEEDBACK)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/")
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	app.renderPrerequisites(c, http.StatusOK, User, gin.H{})
+}
+
+// CataloguePrerequisites draws all modules of the
+// catalogue requiring or required by another one.
+func (app *App) CataloguePrerequisites(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_MODERATE_FEEDBACK)
+	if err != nil {
+		c.String(http.StatusUnauthorized, "Not authorized.")
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	respondGraph(c, app.LoadPrerequisites().Graph.Connected(), 0, "voraussetzungen")
+}
+
+// RebuildPrerequisites extracts the requirements between
+// modules anew, e.g. after importing a new catalogue.
+func (app *App) RebuildPrerequisites(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_MODERATE_FEEDBACK)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/")
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	Count, err := app.RebuildRequirements()
+	if err != nil {
+
+		log.Printf("[RebuildPrerequisites] Storing requirements failed: %s.\n", err.Error())
+
+		app.renderPrerequisites(c, http.StatusInternalServerError, User, gin.H{
+			"FatalError": "Die Voraussetzungen konnten nicht gespeichert werden. Erneut versuchen oder Admin kontaktieren.",
+		})
+
+		return
+	}
+
+	app.renderPrerequisites(c, http.StatusOK, User, gin.H{
+		"Success": fmt.Sprintf("%d Voraussetzungen zwischen Modulen erkannt.", Count),
+	})
+}
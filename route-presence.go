@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strconv"
+
+	"net/http"
+
+	"github.com/freitagsrunde/modulist/db"
+	"github.com/gin-gonic/gin"
+)
+
+// Functions
+
+// presenceModuleID authorizes a presence request and
+// extracts the ID of the module it refers to. If anything
+// does not check out, the request is answered and false
+// returned. Presence requests are sent automatically and
+// thus do not extend the session.
+func (app *App) presenceModuleID(c *gin.Context) (*db.User, int, bool) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_READ)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"Reason": "Not authorized.",
+		})
+
+		return nil, 0, false
+	}
+
+	// Extract ID of module from URL.
+	moduleID, err := strconv.Atoi(c.Param("moduleID"))
+	if (err != nil) || (moduleID < 1) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Reason": "Malformed ID.",
+		})
+
+		return nil, 0, false
+	}
+
+	return User, moduleID, true
+}
+
+// PresenceHeartbeat marks the logged-in user as having a
+// module open and returns who else currently has it open.
+func (app *App) PresenceHeartbeat(c *gin.Context) {
+
+	User, moduleID, ok := app.presenceModuleID(c)
+	if !ok {
+		return
+	}
+
+	app.Presence.Heartbeat(moduleID, *User)
+
+	c.JSON(http.StatusOK, gin.H{
+		"Success": true,
+		"Present": app.Presence.Present(moduleID, User.ID),
+	})
+}
+
+// LeaveModule removes the logged-in user from the users
+// having a module open, sent when the page is closed.
+func (app *App) LeaveModule(c *gin.Context) {
+
+	User, moduleID, ok := app.presenceModuleID(c)
+	if !ok {
+		return
+	}
+
+	app.Presence.Leave(moduleID, User.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"Success": true,
+	})
+}
+
+// ListPresence returns for each module who other than
+// the logged-in user currently has it open.
+func (app *App) ListPresence(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_READ)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"Reason": "Not authorized.",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Success": true,
+		"Modules": app.Presence.All(User.ID),
+	})
+}
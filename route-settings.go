@@ -33,7 +33,7 @@ type UsePasswordLinkPayload struct {
 func (app *App) ListSettings(c *gin.Context) {
 
 	// Check if user is authorized.
-	User, err := app.Authorize(c.Request, db.PRIVILEGE_REVIEWER)
+	User, err := app.Authorize(c.Request, "")
 	if err != nil {
 		c.Redirect(http.StatusFound, "/")
 
@@ -43,7 +43,7 @@ func (app *App) ListSettings(c *gin.Context) {
 	// Update expiration time of session.
 	app.CreateSession(c, *User)
 
-	c.HTML(http.StatusOK, "settings.html", gin.H{
+	app.RenderHTML(c, http.StatusOK, "settings.html", gin.H{
 		"PageTitle": "Einstellungen",
 		"User":      User,
 	})
@@ -52,7 +52,7 @@ func (app *App) ListSettings(c *gin.Context) {
 func (app *App) UpdateSettings(c *gin.Context) {
 
 	// Check if user is authorized.
-	User, err := app.Authorize(c.Request, db.PRIVILEGE_REVIEWER)
+	User, err := app.Authorize(c.Request, "")
 	if err != nil {
 		c.Redirect(http.StatusFound, "/")
 
@@ -64,7 +64,7 @@ func (app *App) UpdateSettings(c *gin.Context) {
 	err = c.BindWith(&Payload, binding.FormPost)
 	if err != nil {
 
-		c.HTML(http.StatusBadRequest, "settings.html", gin.H{
+		app.RenderHTML(c, http.StatusBadRequest, "settings.html", gin.H{
 			"PageTitle":  "Einstellungen",
 			"User":       User,
 			"FatalError": "Gesendete Daten zum Aktualisieren des Passworts konnten nicht verarbeitet werden. Bitte erneut versuchen.",
@@ -78,7 +78,7 @@ func (app *App) UpdateSettings(c *gin.Context) {
 	if ErrorDesc != nil {
 
 		// If payload did not pass, report errors to user.
-		c.HTML(http.StatusBadRequest, "settings.html", gin.H{
+		app.RenderHTML(c, http.StatusBadRequest, "settings.html", gin.H{
 			"PageTitle": "Einstellungen",
 			"User":      User,
 			"Errors":    ErrorDesc,
@@ -93,7 +93,7 @@ func (app *App) UpdateSettings(c *gin.Context) {
 	if (User.ID == "") || (err != nil) {
 
 		// Signal client that an error occured.
-		c.HTML(http.StatusBadRequest, "settings.html", gin.H{
+		app.RenderHTML(c, http.StatusBadRequest, "settings.html", gin.H{
 			"PageTitle":  "Einstellungen",
 			"User":       User,
 			"FatalError": "Das bisherige Passwort ist falsch.",
@@ -106,7 +106,7 @@ func (app *App) UpdateSettings(c *gin.Context) {
 	if Payload.NewPassword != Payload.RepeatedNewPassword {
 
 		// Signal client that an error occured.
-		c.HTML(http.StatusBadRequest, "settings.html", gin.H{
+		app.RenderHTML(c, http.StatusBadRequest, "settings.html", gin.H{
 			"PageTitle":  "Einstellungen",
 			"User":       User,
 			"FatalError": "Die beiden Zeichenketten des neuen Passworts stimmen nicht überein. Bitte dasselbe Passwort zweimal eingeben.",
@@ -120,7 +120,7 @@ func (app *App) UpdateSettings(c *gin.Context) {
 	if err != nil {
 
 		// Signal client that an error occured.
-		c.HTML(http.StatusBadRequest, "settings.html", gin.H{
+		app.RenderHTML(c, http.StatusBadRequest, "settings.html", gin.H{
 			"PageTitle":  "Einstellungen",
 			"User":       User,
 			"FatalError": "Es ist etwas schiefgegangen. Bitte erneut versuchen.",
@@ -135,7 +135,7 @@ func (app *App) UpdateSettings(c *gin.Context) {
 	// Create a JWT and store it as a cookie.
 	app.CreateSession(c, *User)
 
-	c.HTML(http.StatusOK, "settings.html", gin.H{
+	app.RenderHTML(c, http.StatusOK, "settings.html", gin.H{
 		"PageTitle": "Einstellungen",
 		"User":      User,
 		"Success":   "Neues Passwort gespeichert!",
@@ -178,7 +178,7 @@ func (app *App) PasswordLinkView(c *gin.Context) {
 	}
 
 	// Token checks out, display password page.
-	c.HTML(http.StatusOK, "password-link.html", gin.H{
+	app.RenderHTML(c, http.StatusOK, "password-link.html", gin.H{
 		"PageTitle":   "Passwort setzen",
 		"MainTitle":   "Passwort setzen",
 		"SecretToken": Payload.SecretToken,
@@ -223,7 +223,7 @@ func (app *App) UsePasswordLink(c *gin.Context) {
 	err := c.BindWith(&PasswordPayload, binding.FormPost)
 	if err != nil {
 
-		c.HTML(http.StatusBadRequest, "password-link.html", gin.H{
+		app.RenderHTML(c, http.StatusBadRequest, "password-link.html", gin.H{
 			"PageTitle":   "Passwort setzen",
 			"MainTitle":   "Passwort setzen",
 			"SecretToken": TokenPayload.SecretToken,
@@ -238,7 +238,7 @@ func (app *App) UsePasswordLink(c *gin.Context) {
 	if ErrorDesc != nil {
 
 		// If payload did not pass, report errors to user.
-		c.HTML(http.StatusBadRequest, "password-link.html", gin.H{
+		app.RenderHTML(c, http.StatusBadRequest, "password-link.html", gin.H{
 			"PageTitle":   "Passwort setzen",
 			"MainTitle":   "Passwort setzen",
 			"SecretToken": TokenPayload.SecretToken,
@@ -252,7 +252,7 @@ func (app *App) UsePasswordLink(c *gin.Context) {
 	if PasswordPayload.NewPassword != PasswordPayload.RepeatedNewPassword {
 
 		// Signal client that an error occured.
-		c.HTML(http.StatusBadRequest, "password-link.html", gin.H{
+		app.RenderHTML(c, http.StatusBadRequest, "password-link.html", gin.H{
 			"PageTitle":   "Passwort setzen",
 			"MainTitle":   "Passwort setzen",
 			"SecretToken": TokenPayload.SecretToken,
@@ -267,7 +267,7 @@ func (app *App) UsePasswordLink(c *gin.Context) {
 	if err != nil {
 
 		// Signal client that an error occured.
-		c.HTML(http.StatusBadRequest, "password-link.html", gin.H{
+		app.RenderHTML(c, http.StatusBadRequest, "password-link.html", gin.H{
 			"PageTitle":   "Passwort setzen",
 			"MainTitle":   "Passwort setzen",
 			"SecretToken": TokenPayload.SecretToken,
@@ -289,7 +289,7 @@ func (app *App) UsePasswordLink(c *gin.Context) {
 	})
 
 	// Everything went fine. Signal success to user.
-	c.HTML(http.StatusOK, "password-link.html", gin.H{
+	app.RenderHTML(c, http.StatusOK, "password-link.html", gin.H{
 		"PageTitle":   "Passwort setzen",
 		"MainTitle":   "Passwort setzen",
 		"SecretToken": TokenPayload.SecretToken,
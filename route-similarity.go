@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/freitagsrunde/modulist/db"
+	"github.com/gin-gonic/gin"
+)
+
+// Functions
+
+// ListSimilarModules lists all pairs of modules whose
+// learning outcomes, teaching contents or literature
+// are largely the same, e.g. because they were copied.
+func (app *App) ListSimilarModules(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_MODERATE_FEEDBACK)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/")
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	FieldTitles := db.FieldTitles()
+	Titles := make([]string, len(similarityFields))
+	for i, Field := range similarityFields {
+		Titles[i] = FieldTitles[Field]
+	}
+
+	app.RenderHTML(c, http.StatusOK, "admin-similarity.html", gin.H{
+		"PageTitle": "Admin - Ähnliche Module",
+		"User":      User,
+		"Pairs":     app.ListSimilarPairs(),
+		"Modules":   app.Similarity.Len(),
+		"Fields":    Titles,
+		"Threshold": int(SIMILARITY_THRESHOLD * 100),
+	})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	"net/http"
+
+	"github.com/freitagsrunde/modulist/db"
+	"github.com/gin-gonic/gin"
+)
+
+// Constants
+
+const (
+	// How often idle streams send a ping, so that
+	// proxies do not close the connection.
+	FEEDBACK_STREAM_PING = 30 * time.Second
+)
+
+// Functions
+
+// StreamFeedback keeps the module page of a reviewer up to
+// date by sending Server-Sent Events whenever feedback on
+// the module is created, edited or deleted. The stream does
+// not extend the session and ends when the session expires,
+// the browser then reconnects and is authorized again.
+func (app *App) StreamFeedback(c *gin.Context) {
+
+	// Check if user is authorized.
+	_, err := app.Authorize(c.Request, db.PERMISSION_READ)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"Reason": "Not authorized.",
+		})
+
+		return
+	}
+
+	// Extract ID of module to stream events of from URL.
+	moduleID, err := strconv.Atoi(c.Param("moduleID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Reason": "Malformed ID.",
+		})
+
+		return
+	}
+
+	Events := app.Hub.Subscribe(moduleID)
+	defer app.Hub.Unsubscribe(moduleID, Events)
+
+	Ping := time.NewTicker(FEEDBACK_STREAM_PING)
+	defer Ping.Stop()
+
+	Expired := time.After(app.JWTValidFor)
+	Closed := c.Request.Context().Done()
+
+	// Proxies must pass on events right away.
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.Stream(func(w io.Writer) bool {
+
+		select {
+
+		case Event := <-Events:
+			c.SSEvent("feedback", Event)
+
+		case <-Ping.C:
+			c.SSEvent("ping", "")
+
+		case <-Expired:
+			return false
+
+		case <-Closed:
+			return false
+		}
+
+		return true
+	})
+}
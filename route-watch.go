@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"net/http"
+
+	"github.com/freitagsrunde/modulist/db"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Structs
+
+type WatchModulePayload struct {
+	Watch bool `form:"watch"`
+}
+
+// Functions
+
+// WatchModule lets the logged-in user start or stop
+// watching a module reviewed on this page.
+func (app *App) WatchModule(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_READ)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/")
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	// Extract ID of module from URL.
+	id, err := strconv.Atoi(c.Param("moduleID"))
+	if err != nil {
+		c.Redirect(http.StatusFound, "/modules")
+
+		return
+	}
+
+	var Module db.Module
+	app.DB.Select("\"id\"").First(&Module, "\"id\" = ?", id)
+
+	if Module.ID == 0 {
+		c.Redirect(http.StatusFound, "/modules")
+
+		return
+	}
+
+	var Payload WatchModulePayload
+	if err := c.BindWith(&Payload, binding.FormPost); err == nil {
+		db.SetWatch(app.DB, User.ID, Module.ID, Payload.Watch)
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/review/module/%d", Module.ID))
+}
+
+// WatchOwnedModule lets a module owner start or stop
+// watching one of the owned modules.
+func (app *App) WatchOwnedModule(c *gin.Context) {
+
+	User, Module, ok := app.loadOwnedModule(c)
+	if !ok {
+		return
+	}
+
+	var Payload WatchModulePayload
+	if err := c.BindWith(&Payload, binding.FormPost); err == nil {
+		db.SetWatch(app.DB, User.ID, Module.ID, Payload.Watch)
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/owner/module/%d", Module.ID))
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strconv"
+
+	"net/http"
+
+	"github.com/freitagsrunde/modulist/db"
+	"github.com/freitagsrunde/modulist/workload"
+	"github.com/gin-gonic/gin"
+)
+
+// Functions
+
+// ModuleWorkload returns the workload summary of a module
+// as JSON, including all warnings about inconsistencies.
+func (app *App) ModuleWorkload(c *gin.Context) {
+
+	// Check if user is authorized.
+	User, err := app.Authorize(c.Request, db.PERMISSION_READ)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"Reason": "Not authorized.",
+		})
+
+		return
+	}
+
+	// Update expiration time of session.
+	app.CreateSession(c, *User)
+
+	// Extract ID of module from URL.
+	id, err := strconv.Atoi(c.Param("moduleID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"Reason": "Malformed ID.",
+		})
+
+		return
+	}
+
+	var Module db.Module
+	app.DB.Preload("Courses").Preload("WorkingEfforts").First(&Module, "\"id\" = ?", id)
+
+	if Module.URL == "" {
+		c.JSON(http.StatusNotFound, gin.H{
+			"Reason": "Module does not exist.",
+		})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Success":  true,
+		"Workload": workload.Calculate(Module),
+	})
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Constants
+
+const (
+	// Default Content-Security-Policy. All scripts and styles
+	// are served from '/static', inline code is not allowed.
+	DEFAULT_CSP = "default-src 'self'; script-src 'self'; style-src 'self'; img-src 'self' data:; font-src 'self'; connect-src 'self'; object-src 'none'; base-uri 'self'; form-action 'self'; frame-ancestors 'none'"
+
+	// Default max-age of HSTS header: one year.
+	DEFAULT_HSTS_MAX_AGE = 31536000
+)
+
+// Functions
+
+// InitSecurity reads settings concerning cookies,
+// security headers and the HTTPS redirect listener
+// from the .env file.
+func (app *App) InitSecurity() {
+
+	var err error
+
+	// Only send cookies via HTTPS, if requested.
+	app.CookieSecure, err = strconv.ParseBool(os.Getenv("HTTP_COOKIE_SECURE"))
+	if err != nil {
+		log.Fatal("[InitSecurity] Unrecognized HTTP_COOKIE_SECURE indicator in .env, expecting bool. Terminating.")
+	}
+
+	// Restrict cookies to same-site requests.
+	switch strings.ToLower(os.Getenv("HTTP_COOKIE_SAMESITE")) {
+	case "", "strict":
+		app.CookieSameSite = http.SameSiteStrictMode
+	case "lax":
+		app.CookieSameSite = http.SameSiteLaxMode
+	default:
+		log.Fatal("[InitSecurity] Unrecognized HTTP_COOKIE_SAMESITE in .env, expecting 'strict' or 'lax'. Terminating.")
+	}
+
+	// Allow to override the Content-Security-Policy.
+	app.CSP = os.Getenv("HTTP_CSP")
+	if app.CSP == "" {
+		app.CSP = DEFAULT_CSP
+	}
+
+	// Validity of HSTS header in seconds.
+	app.HSTSMaxAge = DEFAULT_HSTS_MAX_AGE
+	if maxAge := os.Getenv("HTTP_HSTS_MAX_AGE"); maxAge != "" {
+
+		app.HSTSMaxAge, err = strconv.Atoi(maxAge)
+		if err != nil {
+			log.Fatal("[InitSecurity] Could not load HTTP_HSTS_MAX_AGE from .env file. Not an integer?")
+		}
+	}
+
+	// Port of optional plain HTTP listener redirecting to HTTPS.
+	app.RedirectPort = os.Getenv("HTTP_REDIRECT_PORT")
+	if (app.RedirectPort != "") && !app.TLS {
+		log.Fatal("[InitSecurity] HTTP_REDIRECT_PORT requires HTTP_TLS to be enabled. Terminating.")
+	}
+}
+
+// SecurityHeaders returns a middleware adding headers
+// that instruct browsers to apply further protections
+// to every response of MODULIST.
+func (app *App) SecurityHeaders() gin.HandlerFunc {
+
+	return func(c *gin.Context) {
+
+		header := c.Writer.Header()
+		header.Set("Content-Security-Policy", app.CSP)
+		header.Set("X-Frame-Options", "DENY")
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("Referrer-Policy", "same-origin")
+
+		// Tell browsers to only ever use HTTPS from now on.
+		if app.TLS && (app.HSTSMaxAge > 0) {
+			header.Set("Strict-Transport-Security", fmt.Sprintf("max-age=%d; includeSubDomains", app.HSTSMaxAge))
+		}
+
+		c.Next()
+	}
+}
+
+// SetCookie stores a cookie in the browser that is never
+// readable by scripts and is restricted according to the
+// security settings from the .env file. A MaxAge of 0 creates
+// a session cookie, a negative one deletes the cookie.
+func (app *App) SetCookie(c *gin.Context, Name string, Value string, MaxAge int, Path string, SameSite http.SameSite) {
+
+	if Path == "" {
+		Path = "/"
+	}
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     Name,
+		Value:    Value,
+		MaxAge:   MaxAge,
+		Path:     Path,
+		Secure:   app.CookieSecure,
+		HttpOnly: true,
+		SameSite: SameSite,
+	})
+}
+
+// RedirectToHTTPS serves the plain HTTP listener on
+// HTTP_REDIRECT_PORT. It answers every request with a
+// permanent redirect to the same location via HTTPS.
+func (app *App) RedirectToHTTPS() {
+
+	redirect := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		// Strip port of plain listener from requested host.
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+
+		if app.Port != "443" {
+			host = net.JoinHostPort(host, app.Port)
+		}
+
+		http.Redirect(w, r, ("https://" + host + r.URL.RequestURI()), http.StatusMovedPermanently)
+	})
+
+	err := http.ListenAndServe(fmt.Sprintf("%s:%s", app.IP, app.RedirectPort), redirect)
+	if err != nil {
+		log.Fatalf("[RedirectToHTTPS] Plain HTTP listener failed: %s. Terminating.", err)
+	}
+}
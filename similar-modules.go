@@ -0,0 +1,174 @@
+package main
+
+import (
+	"math"
+
+	"github.com/freitagsrunde/modulist/db"
+	"github.com/freitagsrunde/modulist/similarity"
+)
+
+// Constants
+
+const (
+	// Share of shingles two texts need to have in common
+	// for their modules to be reported as similar.
+	SIMILARITY_THRESHOLD = 0.5
+)
+
+// Variables
+
+// similarityFields are the free text fields compared
+// to find content copied between modules.
+var similarityFields = []string{"LearningOutcomes", "TeachingContents", "Literature"}
+
+// Structs
+
+// SimilarField is the similarity of one field of two modules.
+// Compared is false if either text was too short to compare.
+type SimilarField struct {
+	Title    string
+	Percent  int
+	Compared bool
+	Similar  bool
+}
+
+// SimilarModule is a module similar to another one.
+type SimilarModule struct {
+	Module db.Module
+	Fields []SimilarField
+}
+
+// SimilarPair is a pair of modules with similar content.
+type SimilarPair struct {
+	Module db.Module
+	Other  db.Module
+	Fields []SimilarField
+}
+
+// Functions
+
+// similarityDocument collects the compared texts of a module.
+func similarityDocument(Module db.Module) similarity.Document {
+
+	Document := similarity.Document{ID: Module.ID, Texts: make(map[string]string)}
+	for _, Field := range similarityFields {
+		Document.Texts[Field], _ = Module.AnchorText(Field)
+	}
+
+	return Document
+}
+
+// similarFields lists supplied scores in the order
+// of similarityFields.
+func similarFields(Scores map[string]float64) []SimilarField {
+
+	Titles := db.FieldTitles()
+	Fields := make([]SimilarField, len(similarityFields))
+
+	for i, Field := range similarityFields {
+
+		Score, Compared := Scores[Field]
+		Fields[i] = SimilarField{
+			Title:    Titles[Field],
+			Percent:  int(math.Round(Score * 100)),
+			Compared: Compared,
+			Similar:  Score >= SIMILARITY_THRESHOLD,
+		}
+	}
+
+	return Fields
+}
+
+// BuildSimilarityIndex indexes the texts of the latest
+// version of each module. Older versions are left out,
+// as they naturally resemble their successors.
+func (app *App) BuildSimilarityIndex() *similarity.Index {
+
+	var Modules []db.Module
+	app.DB.Select("\"id\", \"module_id\", \"version\", \"learning_outcomes\", \"teaching_contents\", \"literature\"").Find(&Modules)
+
+	Latest := make(map[int]db.Module)
+	for _, Module := range Modules {
+
+		if Known, found := Latest[Module.ModuleID]; !found || (Module.Version > Known.Version) {
+			Latest[Module.ModuleID] = Module
+		}
+	}
+
+	Index := similarity.NewIndex(similarityFields)
+	for _, Module := range Latest {
+		Index.Add(similarityDocument(Module))
+	}
+
+	return Index
+}
+
+// loadModules loads title and number of the modules
+// with supplied IDs, keyed by ID.
+func (app *App) loadModules(IDs []int) map[int]db.Module {
+
+	Modules := make(map[int]db.Module)
+	if len(IDs) == 0 {
+		return Modules
+	}
+
+	var Found []db.Module
+	app.DB.Select("\"id\", \"module_id\", \"version\", \"title\"").Where("\"id\" IN (?)", IDs).Find(&Found)
+
+	for _, Module := range Found {
+		Modules[Module.ID] = Module
+	}
+
+	return Modules
+}
+
+// FindSimilarModules returns the modules whose content
+// resembles that of supplied module, most similar first.
+// Other versions of the module itself are left out.
+func (app *App) FindSimilarModules(Module db.Module) []SimilarModule {
+
+	Matches := app.Similarity.Query(similarityDocument(Module), SIMILARITY_THRESHOLD)
+
+	IDs := make([]int, len(Matches))
+	for i, Match := range Matches {
+		IDs[i] = Match.ID
+	}
+
+	Modules := app.loadModules(IDs)
+
+	Similar := []SimilarModule{}
+	for _, Match := range Matches {
+
+		if Other, found := Modules[Match.ID]; found && (Other.ModuleID != Module.ModuleID) {
+			Similar = append(Similar, SimilarModule{Module: Other, Fields: similarFields(Match.Scores)})
+		}
+	}
+
+	return Similar
+}
+
+// ListSimilarPairs returns all pairs of modules with
+// similar content, most similar first.
+func (app *App) ListSimilarPairs() []SimilarPair {
+
+	Pairs := app.Similarity.Pairs(SIMILARITY_THRESHOLD)
+
+	IDs := []int{}
+	for _, Pair := range Pairs {
+		IDs = append(IDs, Pair.ID, Pair.OtherID)
+	}
+
+	Modules := app.loadModules(IDs)
+
+	Similar := []SimilarPair{}
+	for _, Pair := range Pairs {
+
+		Similar = append(Similar, SimilarPair{
+			Module: Modules[Pair.ID],
+			Other:  Modules[Pair.OtherID],
+			Fields: similarFields(Pair.Scores),
+		})
+	}
+
+	return Similar
+}
@@ -0,0 +1,245 @@
+package similarity
+
+import (
+	"sort"
+
+	"hash/fnv"
+
+	"encoding/binary"
+)
+
+// Constants
+
+const (
+	// Texts with fewer words are not compared, as short
+	// phrases like 'Wird in der Vorlesung bekanntgegeben.'
+	// are shared by many modules without being copied.
+	MIN_WORDS = 10
+)
+
+// Structs
+
+// Document is a module to be compared, its texts
+// keyed by the name of the field they belong to.
+type Document struct {
+	ID    int
+	Texts map[string]string
+}
+
+// Match is a document similar to another one. Scores holds
+// the Jaccard similarity per field both documents contain
+// a long enough text in.
+type Match struct {
+	ID     int
+	Scores map[string]float64
+}
+
+// Pair is a pair of similar documents within an index.
+type Pair struct {
+	ID      int
+	OtherID int
+	Scores  map[string]float64
+}
+
+// bucket identifies one band of the signature of a field.
+type bucket struct {
+	Field string
+	Band  int
+	Hash  uint64
+}
+
+// entry holds the shingles of the fields of a document.
+type entry struct {
+	Shingles map[string]map[uint64]bool
+}
+
+// Index holds the documents of a catalogue and finds those
+// similar to each other. Documents sharing a band of the
+// signature of a field are candidates, whose similarity
+// is then computed exactly.
+type Index struct {
+	Fields  []string
+	entries map[int]entry
+	buckets map[bucket][]int
+}
+
+// Functions
+
+// NewIndex returns an empty index comparing supplied fields.
+func NewIndex(Fields []string) *Index {
+
+	return &Index{
+		Fields:  Fields,
+		entries: make(map[int]entry),
+		buckets: make(map[bucket][]int),
+	}
+}
+
+// Len returns the amount of documents in the index.
+func (index *Index) Len() int {
+	return len(index.entries)
+}
+
+// prepare computes the shingles of all fields of supplied
+// document that are long enough to be compared.
+func (index *Index) prepare(Document Document) entry {
+
+	Entry := entry{Shingles: make(map[string]map[uint64]bool)}
+
+	for _, Field := range index.Fields {
+
+		if Text := Document.Texts[Field]; len(Words(Text)) >= MIN_WORDS {
+			Entry.Shingles[Field] = Shingles(Text)
+		}
+	}
+
+	return Entry
+}
+
+// bands returns the buckets the signatures of
+// supplied entry fall into.
+func bands(Entry entry) []bucket {
+
+	Buckets := []bucket{}
+
+	for Field, Shingles := range Entry.Shingles {
+
+		Signature := NewSignature(Shingles)
+
+		for Band := 0; Band < BANDS; Band++ {
+
+			Hash := fnv.New64a()
+			for _, Value := range Signature[(Band * ROWS):((Band + 1) * ROWS)] {
+				binary.Write(Hash, binary.LittleEndian, Value)
+			}
+
+			Buckets = append(Buckets, bucket{Field: Field, Band: Band, Hash: Hash.Sum64()})
+		}
+	}
+
+	return Buckets
+}
+
+// Add puts supplied document into the index.
+func (index *Index) Add(Document Document) {
+
+	Entry := index.prepare(Document)
+	index.entries[Document.ID] = Entry
+
+	for _, Bucket := range bands(Entry) {
+		index.buckets[Bucket] = append(index.buckets[Bucket], Document.ID)
+	}
+}
+
+// scores computes the similarity per field of two entries.
+func scores(a entry, b entry) map[string]float64 {
+
+	Scores := make(map[string]float64)
+
+	for Field, Shingles := range a.Shingles {
+
+		if Other, found := b.Shingles[Field]; found {
+			Scores[Field] = Jaccard(Shingles, Other)
+		}
+	}
+
+	return Scores
+}
+
+// Max returns the highest of supplied scores.
+func Max(Scores map[string]float64) float64 {
+
+	Max := 0.0
+	for _, Score := range Scores {
+
+		if Score > Max {
+			Max = Score
+		}
+	}
+
+	return Max
+}
+
+// Query returns the documents of the index similar to
+// supplied one in at least one field, most similar first.
+// Similarity is at least Threshold, between 0 and 1. A
+// document of the index with the same ID is left out.
+func (index *Index) Query(Document Document, Threshold float64) []Match {
+
+	Entry := index.prepare(Document)
+
+	Candidates := make(map[int]bool)
+	for _, Bucket := range bands(Entry) {
+
+		for _, ID := range index.buckets[Bucket] {
+
+			if ID != Document.ID {
+				Candidates[ID] = true
+			}
+		}
+	}
+
+	Matches := []Match{}
+	for ID := range Candidates {
+
+		if Scores := scores(Entry, index.entries[ID]); Max(Scores) >= Threshold {
+			Matches = append(Matches, Match{ID: ID, Scores: Scores})
+		}
+	}
+
+	sort.Slice(Matches, func(i, j int) bool {
+
+		if a, b := Max(Matches[i].Scores), Max(Matches[j].Scores); a != b {
+			return a > b
+		}
+
+		return Matches[i].ID < Matches[j].ID
+	})
+
+	return Matches
+}
+
+// Pairs returns all pairs of documents of the index similar
+// in at least one field, most similar first. Similarity is
+// at least Threshold, between 0 and 1.
+func (index *Index) Pairs(Threshold float64) []Pair {
+
+	Candidates := make(map[[2]int]bool)
+	for _, IDs := range index.buckets {
+
+		for i := range IDs {
+
+			for j := (i + 1); j < len(IDs); j++ {
+
+				if IDs[i] < IDs[j] {
+					Candidates[[2]int{IDs[i], IDs[j]}] = true
+				} else if IDs[i] > IDs[j] {
+					Candidates[[2]int{IDs[j], IDs[i]}] = true
+				}
+			}
+		}
+	}
+
+	Pairs := []Pair{}
+	for Candidate := range Candidates {
+
+		if Scores := scores(index.entries[Candidate[0]], index.entries[Candidate[1]]); Max(Scores) >= Threshold {
+			Pairs = append(Pairs, Pair{ID: Candidate[0], OtherID: Candidate[1], Scores: Scores})
+		}
+	}
+
+	sort.Slice(Pairs, func(i, j int) bool {
+
+		if a, b := Max(Pairs[i].Scores), Max(Pairs[j].Scores); a != b {
+			return a > b
+		}
+
+		if Pairs[i].ID != Pairs[j].ID {
+			return Pairs[i].ID < Pairs[j].ID
+		}
+
+		return Pairs[i].OtherID < Pairs[j].OtherID
+	})
+
+	return Pairs
+}
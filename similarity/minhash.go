@@ -0,0 +1,151 @@
+package similarity
+
+import (
+	"strings"
+	"unicode"
+
+	"hash/fnv"
+)
+
+// Constants
+
+const (
+	// Words per shingle. Three words are specific enough
+	// that common phrases rarely make texts look similar.
+	SHINGLE_SIZE = 3
+
+	// Bands and rows per band of signatures. Texts agreeing
+	// in all rows of any band are compared exactly. With these
+	// values, texts sharing half of their shingles become
+	// candidates almost surely, unrelated ones hardly ever.
+	BANDS = 42
+	ROWS  = 3
+
+	// Amount of hash functions of a signature.
+	SIGNATURE_SIZE = BANDS * ROWS
+)
+
+// Structs
+
+// Signature is the MinHash signature of a set of shingles.
+// Two signatures agree in a position with a probability
+// equal to the Jaccard similarity of their sets, so similar
+// sets can be found by comparing parts of signatures.
+type Signature []uint64
+
+// Variables
+
+// seeds derive the hash functions of signatures from
+// the hash of a shingle. They are fixed so that signatures
+// stay comparable between runs.
+var seeds = makeSeeds(SIGNATURE_SIZE)
+
+// Functions
+
+// mix scrambles the bits of x (SplitMix64 finalizer).
+func mix(x uint64) uint64 {
+
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+
+	return x
+}
+
+// makeSeeds returns Count pseudo-random seeds.
+func makeSeeds(Count int) []uint64 {
+
+	Seeds := make([]uint64, Count)
+
+	State := uint64(0x9e3779b97f4a7c15)
+	for i := range Seeds {
+		State += 0x9e3779b97f4a7c15
+		Seeds[i] = mix(State)
+	}
+
+	return Seeds
+}
+
+// Words splits supplied text into lowercase words, dropping
+// punctuation and everything else not part of a word.
+func Words(Text string) []string {
+
+	return strings.FieldsFunc(strings.ToLower(Text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Shingles returns the hashes of all runs of SHINGLE_SIZE
+// consecutive words of supplied text. Texts shorter than
+// that form a single shingle, empty texts none.
+func Shingles(Text string) map[uint64]bool {
+
+	Words := Words(Text)
+	Shingles := make(map[uint64]bool)
+
+	if len(Words) == 0 {
+		return Shingles
+	}
+
+	Size := SHINGLE_SIZE
+	if len(Words) < Size {
+		Size = len(Words)
+	}
+
+	for i := 0; (i + Size) <= len(Words); i++ {
+
+		Hash := fnv.New64a()
+		Hash.Write([]byte(strings.Join(Words[i:(i+Size)], " ")))
+
+		Shingles[Hash.Sum64()] = true
+	}
+
+	return Shingles
+}
+
+// NewSignature computes the MinHash signature of
+// supplied shingles.
+func NewSignature(Shingles map[uint64]bool) Signature {
+
+	Signature := make(Signature, SIGNATURE_SIZE)
+	for i := range Signature {
+		Signature[i] = ^uint64(0)
+	}
+
+	for Shingle := range Shingles {
+
+		for i, Seed := range seeds {
+
+			if Hash := mix(Shingle ^ Seed); Hash < Signature[i] {
+				Signature[i] = Hash
+			}
+		}
+	}
+
+	return Signature
+}
+
+// Jaccard returns the share of shingles two sets have in
+// common, relative to all shingles of both of them.
+func Jaccard(a map[uint64]bool, b map[uint64]bool) float64 {
+
+	if (len(a) == 0) || (len(b) == 0) {
+		return 0
+	}
+
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+
+	Common := 0
+	for Shingle := range a {
+
+		if b[Shingle] {
+			Common++
+		}
+	}
+
+	return float64(Common) / float64(len(a)+len(b)-Common)
+}
@@ -6,7 +6,7 @@ function saveMailHeader() {
 
   $.post("/admin/send-feedback/mail-header", newtext, function(data) {
 
-    if (data) {
+    if (data.Success) {
 
       $("#mailheader-success").fadeIn();
 
@@ -25,7 +25,7 @@ function saveMailFooter() {
 
   $.post("/admin/send-feedback/mail-footer", newtext, function(data) {
 
-    if (data) {
+    if (data.Success) {
 
       $("#mailfooter-success").fadeIn();
 
@@ -36,17 +36,29 @@ function saveMailFooter() {
   });
 }
 
-function sendOutFeedback(depMailAddress, depMailContent) {
+function sendOutFeedback(button) {
 
-    var feedback = {
-        mail: depMailAddress,
-        content: depMailContent
-    };
+    // Send with the same filter the mail was previewed with.
+    var feedback = button.data("filter") + "&mail=" + encodeURIComponent(button.data("mail"));
+
+    button.prop("disabled", true);
 
     $.post("/admin/send-feedback", feedback, function(retData) {
 
-        if(retData.ReturnValue) {
-            $("#button-" + depMailAddress).innerHTML = '<p class = "text-info">Versendet!</p>'
+        if (retData.Success) {
+            button.replaceWith($("<p class = \"text-info\"></p>").text("Versendet!"));
         }
+    }).fail(function() {
+        button.prop("disabled", false);
+    });
+}
+
+$(function() {
+
+    $("#save-mail-header").click(saveMailHeader);
+    $("#save-mail-footer").click(saveMailFooter);
+
+    $(".send-feedback").click(function() {
+        sendOutFeedback($(this));
     });
-}
\ No newline at end of file
+})
\ No newline at end of file
@@ -0,0 +1,182 @@
+// Passages selected per category, waiting to be
+// submitted together with a new comment.
+var pendingAnchors = {};
+
+// Offsets are counted in UTF-16 code units of the text
+// content of a field, just as the server counts them.
+function anchorOffsets(container, range) {
+
+    var before = document.createRange();
+    before.selectNodeContents(container);
+    before.setEnd(range.startContainer, range.startOffset);
+
+    var start = before.toString().length;
+
+    return {
+        start: start,
+        end: start + range.toString().length
+    };
+}
+
+function renderAnchorPreview(catID) {
+
+    var preview = $("#anchor-preview-" + catID);
+
+    if (preview.length === 0) {
+        preview = $("<div class = \"anchor-preview\"></div>").attr("id", "anchor-preview-" + catID);
+        $("#comment-form-" + catID).before(preview);
+    }
+
+    preview.empty();
+
+    var anchor = pendingAnchors[catID];
+    if (!anchor) {
+        preview.hide();
+        return;
+    }
+
+    preview.append($("<span></span>").text("Bezogen auf: „" + anchor.quote + "“"));
+    preview.append($("<button class = \"btn btn-link btn-xs anchor-remove\"></button>").data("category", catID).text("Entfernen"));
+    preview.show();
+}
+
+function selectAnchor(container) {
+
+    var selection = window.getSelection();
+    if (selection.rangeCount === 0 || selection.isCollapsed) {
+        return;
+    }
+
+    var range = selection.getRangeAt(0);
+
+    // Passages can only be anchored within one field.
+    if (!container.contains(range.startContainer) || !container.contains(range.endContainer)) {
+        return;
+    }
+
+    var quote = range.toString();
+    if ($.trim(quote) === "") {
+        return;
+    }
+
+    var offsets = anchorOffsets(container, range);
+    var catID = $(container).data("category");
+
+    pendingAnchors[catID] = {
+        field: $(container).data("field"),
+        start: offsets.start,
+        end: offsets.end,
+        quote: quote
+    };
+
+    renderAnchorPreview(catID);
+}
+
+// takeAnchor returns the passage selected for a category
+// as form values, or an empty object if there is none.
+function takeAnchor(catID) {
+
+    var anchor = pendingAnchors[catID];
+    if (!anchor) {
+        return {};
+    }
+
+    return {
+        "anchor-field": anchor.field,
+        "anchor-start": anchor.start,
+        "anchor-end": anchor.end,
+        "anchor-quote": anchor.quote
+    };
+}
+
+function clearAnchor(catID) {
+    delete pendingAnchors[catID];
+    renderAnchorPreview(catID);
+}
+
+function clearHighlights() {
+
+    $("mark.anchor-highlight").contents().unwrap();
+
+    $(".anchorable").each(function() {
+        this.normalize();
+    });
+}
+
+function highlightPassage(container, start, end, feedbackID) {
+
+    // Collect text nodes first, splitting them below
+    // would confuse the walker.
+    var walker = document.createTreeWalker(container, NodeFilter.SHOW_TEXT, null, false);
+    var nodes = [];
+
+    while (walker.nextNode()) {
+        nodes.push(walker.currentNode);
+    }
+
+    var offset = 0;
+
+    for (var i = 0; i < nodes.length; i++) {
+
+        var node = nodes[i];
+        var length = node.data.length;
+        var from = Math.max(start, offset) - offset;
+        var to = Math.min(end, offset + length) - offset;
+
+        offset += length;
+
+        if (from >= to) {
+            continue;
+        }
+
+        if (from > 0) {
+            node = node.splitText(from);
+        }
+
+        if ((to - from) < node.data.length) {
+            node.splitText(to - from);
+        }
+
+        $(node).wrap($("<mark class = \"anchor-highlight\"></mark>").attr("data-feedback", feedbackID));
+    }
+}
+
+// highlightAnchors marks the passages all supplied
+// discussions refer to, if they are still present.
+function highlightAnchors(threads) {
+
+    clearHighlights();
+
+    for (var i = 0; i < threads.length; i++) {
+
+        var thread = threads[i];
+        if (!thread.AnchorField || !thread.AnchorFound) {
+            continue;
+        }
+
+        var container = $(".anchorable[data-field=" + thread.AnchorField + "]").get(0);
+        if (container) {
+            highlightPassage(container, thread.AnchorStart, thread.AnchorEnd, thread.ID);
+        }
+    }
+}
+
+$(function() {
+
+    $(".anchorable").on("mouseup", function() {
+        selectAnchor(this);
+    });
+
+    $(document).on("click", ".anchor-remove", function() {
+        clearAnchor($(this).data("category"));
+    });
+
+    // Highlighted passages lead to the comment on them.
+    $(document).on("click", "mark.anchor-highlight", function() {
+
+        var comment = document.getElementById("feedback-" + $(this).data("feedback"));
+        if (comment) {
+            comment.scrollIntoView();
+        }
+    });
+})
\ No newline at end of file
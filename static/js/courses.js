@@ -0,0 +1,8 @@
+$(function() {
+
+    $('#coursesList').DataTable({
+        "order": [[ 0, "asc" ]],
+        "paging": false,
+        "info": false
+    });
+})
\ No newline at end of file
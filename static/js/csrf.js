@@ -0,0 +1,10 @@
+// Send the CSRF token of this session along with
+// every state-changing AJAX request.
+$.ajaxSetup({
+    beforeSend: function(xhr, settings) {
+
+        if (!/^(GET|HEAD|OPTIONS)$/i.test(settings.type)) {
+            xhr.setRequestHeader("X-CSRF-Token", $("meta[name='csrf-token']").attr("content"));
+        }
+    }
+});
\ No newline at end of file
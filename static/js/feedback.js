@@ -1,51 +1,502 @@
-function submitFeedback(moduleID, catID) {
+// What the logged-in user may do with feedback,
+// updated with every response of the server.
+var feedbackMeta = {
+    UserID: "",
+    CanReview: false,
+    CanModerate: false,
+    Severities: {},
+    SeverityOrder: [],
+    Statuses: {},
+    StatusOrder: [],
+    Fields: {},
+    StatusGroups: {}
+};
+
+function renderSelect(name, order, titles, selected) {
+
+    var select = $("<select class = \"form-control input-sm\"></select>").attr("name", name);
+
+    for (var i = 0; i < order.length; i++) {
+        select.append($("<option></option>").val(order[i]).text(titles[order[i]]).prop("selected", order[i] === selected));
+    }
+
+    return select;
+}
+
+function renderClassification(moduleID, comment) {
+
+    var labels = $("<div class = \"feedback-labels\"></div>");
+
+    labels.append($("<span class = \"label\"></span>").addClass("severity-" + comment.Severity)
+        .text(feedbackMeta.Severities[comment.Severity]));
+    labels.append(" ");
+    labels.append($("<span class = \"label label-default\"></span>").text(feedbackMeta.Statuses[comment.Status]));
+
+    var tags = comment.Tags ? comment.Tags.split(",") : [];
+    for (var i = 0; i < tags.length; i++) {
+        labels.append(" ");
+        labels.append($("<span class = \"label label-info\"></span>").text(tags[i]));
+    }
+
+    return labels;
+}
+
+function renderClassifyForm(moduleID, comment) {
+
+    var form = $("<div class = \"form-inline feedback-classify-form\"></div>");
+
+    form.append(renderSelect("severity", feedbackMeta.SeverityOrder, feedbackMeta.Severities, comment.Severity));
+    form.append($("<input type = \"text\" class = \"form-control input-sm\" name = \"tags\" />").val(comment.Tags.split(",").join(", ")));
+
+    if (feedbackMeta.CanModerate) {
+        form.append(renderSelect("status", feedbackMeta.StatusOrder, feedbackMeta.Statuses, comment.Status));
+    }
+
+    form.append($("<button class = \"btn btn-default btn-xs feedback-classify\"></button>")
+        .data("module", moduleID).data("id", comment.ID).text("Einordnen"));
+
+    return form;
+}
+
+function renderSuggestion(comment) {
+
+    var suggestion = $("<div class = \"feedback-suggestion\"></div>");
+    var heading = $("<h5></h5>").text("Änderungsvorschlag für " + feedbackMeta.Fields[comment.SuggestionField]);
+
+    if (comment.SuggestionOutdated) {
+        heading.append(" ").append($("<small></small>").text("(der Modultext wurde seitdem geändert)"));
+    }
+
+    var text = $("<p class = \"suggestion-diff\"></p>");
+    var segments = comment.Diff || [];
+
+    for (var i = 0; i < segments.length; i++) {
+
+        if (segments[i].Op === "delete") {
+            text.append($("<del></del>").text(segments[i].Text));
+        } else if (segments[i].Op === "insert") {
+            text.append($("<ins></ins>").text(segments[i].Text));
+        } else {
+            text.append(document.createTextNode(segments[i].Text));
+        }
+    }
+
+    return suggestion.append(heading).append(text);
+}
+
+function renderVotes(moduleID, comment) {
+
+    var votes = $("<div class = \"feedback-votes\"></div>");
+
+    // Reviewers may support comments of others.
+    if (feedbackMeta.CanReview && (comment.UserID !== feedbackMeta.UserID)) {
+        votes.append($("<button class = \"btn btn-xs feedback-vote\"></button>")
+            .addClass(comment.Voted ? "btn-success" : "btn-default")
+            .data("module", moduleID).data("id", comment.ID).text("+1"));
+        votes.append(" ");
+    }
+
+    var text = comment.Votes === 1 ? "1 Zustimmung" : comment.Votes + " Zustimmungen";
+
+    // Show which status groups the supporters belong to.
+    var groups = [];
+    for (var group in comment.VotesByGroup) {
+        groups.push(feedbackMeta.StatusGroups[group] + ": " + comment.VotesByGroup[group]);
+    }
+
+    if (groups.length > 0) {
+        text += " (" + groups.join(", ") + ")";
+    }
+
+    return votes.append($("<small></small>").text(text));
+}
+
+function renderComment(moduleID, comment, isReply) {
+
+    var element = $("<div></div>").addClass(isReply ? "feedback-reply" : "feedback-thread");
+    element.attr("id", "feedback-" + comment.ID);
+
+    if (isReply && comment.Consensus) {
+        element.addClass("feedback-consensus");
+        element.append($("<span class = \"label label-success\"></span>").text("Konsens"));
+    }
+
+    if (!isReply) {
+        element.append(renderClassification(moduleID, comment));
+    }
+
+    if (!isReply && comment.AnchorField) {
+
+        var anchor = $("<p class = \"feedback-anchor\"></p>").text("Textstelle: „" + comment.AnchorQuote + "“");
+
+        if (!comment.AnchorFound) {
+            anchor.append(" ").append($("<small></small>").text("(im aktuellen Modultext nicht mehr enthalten)"));
+        }
+
+        element.append(anchor);
+    }
+
+    // Comments are user input, only ever insert the
+    // rendering the server sanitized as HTML.
+    if (comment.Comment) {
+        element.append($("<div class = \"feedback-comment\"></div>").html(comment.CommentHTML));
+    }
+
+    if (comment.Kind === "suggestion") {
+        element.append(renderSuggestion(comment));
+    }
+
+    if (!isReply) {
+        element.append(renderVotes(moduleID, comment));
+    }
+
+    var actions = $("<div class = \"feedback-actions\"></div>");
+
+    if ((comment.UserID === feedbackMeta.UserID) || feedbackMeta.CanModerate) {
+
+        actions.append($("<button class = \"btn btn-link btn-xs feedback-delete\"></button>")
+            .data("module", moduleID).data("id", comment.ID).text("Löschen"));
+
+        if (!isReply) {
+            actions.append(renderClassifyForm(moduleID, comment));
+        }
+    }
+
+    if (isReply && feedbackMeta.CanModerate) {
+        actions.append($("<button class = \"btn btn-link btn-xs feedback-consensus-toggle\"></button>")
+            .data("module", moduleID).data("id", comment.ID).text(comment.Consensus ? "Kein Konsens" : "Als Konsens markieren"));
+    }
+
+    element.append(actions);
+
+    return element;
+}
+
+function renderCategory(moduleID, catID, threads) {
+
+    // Keep replies being written while the discussions
+    // are rendered again, e.g. due to live updates.
+    var drafts = {};
+    $("#comment-view-" + catID + " textarea[id^=reply-form-]").each(function() {
+        if (this.value !== "") {
+            drafts[this.id] = this.value;
+        }
+    });
+
+    var view = $("#comment-view-" + catID).empty();
+
+    for (var i = 0; i < threads.length; i++) {
+
+        var thread = renderComment(moduleID, threads[i], false);
+        var replies = threads[i].Replies || [];
+
+        for (var j = 0; j < replies.length; j++) {
+            thread.append(renderComment(moduleID, replies[j], true));
+        }
+
+        if (feedbackMeta.CanReview) {
+
+            var form = $("<div class = \"feedback-reply-form\"></div>");
+            form.append($("<textarea class = \"form-control feedback-textarea\" rows = \"2\" placeholder = \"Antworten...\"></textarea>")
+                .attr("id", "reply-form-" + threads[i].ID));
+            form.append($("<button class = \"btn btn-default btn-sm reply-submit\"></button>")
+                .data("module", moduleID).data("category", catID).data("parent", threads[i].ID).text("Antworten"));
+
+            thread.append(form);
+        }
+
+        view.append(thread);
+    }
+
+    for (var id in drafts) {
+        $("#" + id).val(drafts[id]);
+    }
+
+    $("#comment-header-" + catID + " .badge").text(threads.length);
+}
+
+function applyFeedback(moduleID, data, catIDs) {
+
+    feedbackMeta.UserID = data.UserID;
+    feedbackMeta.CanReview = data.CanReview;
+    feedbackMeta.CanModerate = data.CanModerate;
+    feedbackMeta.Severities = data.Severities;
+    feedbackMeta.SeverityOrder = data.SeverityOrder;
+    feedbackMeta.Statuses = data.Statuses;
+    feedbackMeta.StatusOrder = data.StatusOrder;
+    feedbackMeta.Fields = data.Fields;
+    feedbackMeta.StatusGroups = data.StatusGroups;
+
+    // Group discussions by category.
+    var byCategory = {};
+    for (var i = 0; i < data.Feedback.length; i++) {
+
+        var catID = data.Feedback[i].Category;
+        byCategory[catID] = byCategory[catID] || [];
+        byCategory[catID].push(data.Feedback[i]);
+    }
+
+    for (var j = 0; j < catIDs.length; j++) {
+        renderCategory(moduleID, catIDs[j], byCategory[catIDs[j]] || []);
+    }
+}
+
+// Canned comments inserted into the comment box of a
+// category, to count their usage once submitted.
+var usedCannedComments = {};
+
+function insertCannedComment(select) {
+
+    var option = select.find("option:selected");
+    var catID = select.data("category");
+
+    if (option.val() === "") {
+        return;
+    }
+
+    var field = $("#comment-form-" + catID);
+    var text = field.val();
+
+    field.val(text === "" ? option.data("comment") : text + "\n\n" + option.data("comment"));
+    usedCannedComments[catID] = option.val();
+
+    select.val("");
+}
+
+function submitFeedback(moduleID, catID, parentID) {
+
+    var field = parentID ? $("#reply-form-" + parentID) : $("#comment-form-" + catID);
 
     var obj = {
         category: catID,
-        comment: $("#comment-form-" + catID).val()
+        comment: field.val(),
+        parent: parentID || 0
     };
 
+    if (!parentID) {
+        obj.severity = $("#severity-form-" + catID).val();
+        obj.tags = $("#tags-form-" + catID).val();
+        $.extend(obj, takeAnchor(catID));
+
+        if (usedCannedComments[catID]) {
+            obj.canned = usedCannedComments[catID];
+        }
+    }
+
     $.post("/review/module/" + moduleID + "/add", obj, function(data) {
 
         if (data.Success) {
+            field.val("");
+            $("#tags-form-" + catID).val("");
 
-            for (var i = 0; i < data.Feedback.length; i++) {
-                $("#comment-view-" + catID)[0].innerHTML += "<p>" + data.Feedback[i].Comment + "</p>";
+            if (!parentID) {
+                clearAnchor(catID);
+                delete usedCannedComments[catID];
             }
-            $("#comment-form-" + catID).val("");
-            $("#comment-header-" + catID)[0].innerHTML = "Feedback <span class = \"badge\">" + data.Count + "</span>";
+
+            loadFeedback(moduleID);
+        }
+    });
+}
+
+// adoptFinding turns a finding of the plausibility
+// checks into a comment on its category.
+function adoptFinding(button) {
+
+    var moduleID = button.data("module");
+
+    $.post("/review/module/" + moduleID + "/add", {
+        category: button.data("category"),
+        comment: button.data("message"),
+        severity: button.data("severity"),
+        tags: "plausibilität",
+        parent: 0
+    }, function(data) {
+
+        if (data.Success) {
+            button.prop("disabled", true).removeClass("lint-adopt").text("Übernommen");
+            loadFeedback(moduleID);
         }
     });
 }
 
-function deleteFeedback(id) {
+function deleteFeedback(moduleID, id) {
 
     if (confirm("Soll das abgegebene Feedback wirklich gelöscht werden?")) {
 
-        $.get("/deleteFeedback/" + id, function(data) {
-            if (data === true) {
-                location.reload();
+        $.post("/review/module/" + moduleID + "/delete/" + id, function(data) {
+            if (data.Success) {
+                loadFeedback(moduleID);
             }
         });
     }
 }
 
-function updateAllCounts(moduleID) {
+function toggleConsensus(moduleID, id) {
+
+    $.post("/review/module/" + moduleID + "/consensus/" + id, function(data) {
+
+        if (data.Success) {
+            loadFeedback(moduleID);
+        }
+    });
+}
+
+function voteFeedback(moduleID, id) {
 
-    $.get("/review/module/" + moduleID + "/comments", function(data) {
+    $.post("/review/module/" + moduleID + "/vote/" + id, function(data) {
 
         if (data.Success) {
+            loadFeedback(moduleID);
+        }
+    });
+}
+
+function classifyFeedback(moduleID, id, form) {
+
+    var obj = {
+        severity: form.find("[name=severity]").val()
+    };
 
-            // TODO: Finish this.
-            console.log(data.Feedback);
+    if (form.find("[name=tags]").length > 0) {
+        obj.tags = form.find("[name=tags]").val();
+    }
+
+    if (form.find("[name=status]").length > 0) {
+        obj.status = form.find("[name=status]").val();
+    }
+
+    $.post("/review/module/" + moduleID + "/classify/" + id, obj, function(data) {
+
+        if (data.Success) {
+            loadFeedback(moduleID);
         }
     });
 }
 
+// Whether the comment linked in the address, e.g. by a
+// notification, was already scrolled to.
+var scrolledToHash = false;
+
+function scrollToHash() {
+
+    if (scrolledToHash || (window.location.hash.indexOf("#feedback-") !== 0)) {
+        return;
+    }
+
+    var target = $(window.location.hash);
+    if (target.length > 0) {
+        scrolledToHash = true;
+        target.addClass("feedback-linked");
+        $("html, body").scrollTop(target.offset().top);
+    }
+}
+
+// Only show discussions matching the chosen filter.
+function currentFilter() {
+
+    return $("#feedback-filter :input").filter(function() {
+        return this.value !== "";
+    }).serialize();
+}
+
+function loadFeedback(moduleID) {
+
+    $.get("/review/module/" + moduleID + "/comments?" + currentFilter(), function(data) {
+
+        if (data.Success) {
+
+            // Render every category present on this page.
+            var catIDs = $("[id^=comment-view-]").map(function() {
+                return parseInt(this.id.replace("comment-view-", ""), 10);
+            }).get();
+
+            applyFeedback(moduleID, data, catIDs);
+            highlightAnchors(data.Feedback);
+            scrollToHash();
+        }
+    });
+}
+
+// refreshCategory renders the discussions of one category
+// again, leaving all other categories untouched.
+function refreshCategory(moduleID, catID) {
+
+    $.get("/review/module/" + moduleID + "/comments?" + currentFilter(), function(data) {
+
+        if (data.Success) {
+            applyFeedback(moduleID, data, [catID]);
+            highlightAnchors(data.Feedback);
+        }
+    });
+}
+
+// listenForFeedback merges feedback created, edited or
+// deleted by others into the page as soon as it happens.
+function listenForFeedback(moduleID) {
+
+    if (!window.EventSource) {
+        return;
+    }
+
+    var source = new EventSource("/review/module/" + moduleID + "/events");
+
+    source.addEventListener("feedback", function(message) {
+
+        var event = JSON.parse(message.data);
+
+        // Own changes are displayed already.
+        if (event.ActorID === feedbackMeta.UserID) {
+            return;
+        }
+
+        refreshCategory(moduleID, event.Category);
+    });
+}
+
 $(function() {
 
     var path = window.location.pathname;
     var moduleID = path.split("/")[3];
 
-    updateAllCounts(moduleID);
+    $(".canned-comment").change(function() {
+        insertCannedComment($(this));
+    });
+
+    $(".lint-adopt").click(function() {
+        adoptFinding($(this));
+    });
+
+    $(".feedback-submit").click(function() {
+        submitFeedback($(this).data("module"), $(this).data("category"), 0);
+    });
+
+    // Elements of discussions are rendered dynamically.
+    $(document).on("click", ".reply-submit", function() {
+        submitFeedback($(this).data("module"), $(this).data("category"), $(this).data("parent"));
+    });
+
+    $(document).on("click", ".feedback-delete", function() {
+        deleteFeedback($(this).data("module"), $(this).data("id"));
+    });
+
+    $(document).on("click", ".feedback-consensus-toggle", function() {
+        toggleConsensus($(this).data("module"), $(this).data("id"));
+    });
+
+    $(document).on("click", ".feedback-vote", function() {
+        voteFeedback($(this).data("module"), $(this).data("id"));
+    });
+
+    $(document).on("click", ".feedback-classify", function() {
+        classifyFeedback($(this).data("module"), $(this).data("id"), $(this).closest(".feedback-classify-form"));
+    });
+
+    $("#feedback-filter").submit(function(event) {
+        event.preventDefault();
+        loadFeedback(moduleID);
+    });
+
+    loadFeedback(moduleID);
+    listenForFeedback(moduleID);
 })
\ No newline at end of file
@@ -0,0 +1,12 @@
+function blah() {
+    var e = $($("path")[Math.floor(Math.random() * $("path").length)])
+    e.attr("class", "hover");
+    setTimeout(function(){e.attr("class", "")}, 50);
+}
+
+$(setInterval(blah, 250));
+$(setInterval(blah, 300));
+
+$(function() {
+    $("path").hover(function(){$(this).attr("class", "hover")}, function(){$(this).attr("class", "")});
+});
\ No newline at end of file
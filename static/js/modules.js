@@ -1,6 +1,6 @@
 function checkModule(id) {
 
-    $.get("/done/" + id, function(data) {
+    $.post("/modules/done/" + id, function(data) {
 
         if (data.Done !== undefined) {
 
@@ -57,7 +57,7 @@ function updateVisibility(fast) {
 
 function feedbackCheck(id) {
 
-    $.get("/done/" + id, function(data) {
+    $.post("/modules/done/" + id, function(data) {
 
         if (data.Done !== undefined) {
 
@@ -107,8 +107,22 @@ function readCookie(name) {
     return null;
 }
 
+$.extend(true, $.fn.dataTable.defaults, {
+    "searching": false
+});
+
 $(function() {
 
+    $('#modulesList').DataTable({
+        "order": [[ 2, "asc" ]],
+        "paging": false,
+        "info": false,
+        "fixedHeader": {
+            "header": true,
+            "footer": false
+        }
+    });
+
     if (readCookie("hideDone") === "1") {
         $("#modules-list-approved-checkbox")[0].checked=true
         updateVisibility(true);
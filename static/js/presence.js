@@ -0,0 +1,88 @@
+// Seconds between heartbeats of an open module page.
+var presenceInterval = 30;
+
+function presenceNames(present) {
+
+    return $.map(present, function(presence) {
+        return presence.Name;
+    }).join(", ");
+}
+
+// renderModulePresence shows on a module page who
+// else currently has the module open.
+function renderModulePresence(element, present) {
+
+    element.empty();
+
+    if (present.length > 0) {
+        element.append($("<span class = \"glyphicon glyphicon-user\"></span>"));
+        element.append(document.createTextNode(" Gerade ebenfalls geöffnet von: " + presenceNames(present)));
+    }
+}
+
+function sendHeartbeat(element) {
+
+    $.post("/review/module/" + element.data("module") + "/presence", function(data) {
+        if (data.Success) {
+            renderModulePresence(element, data.Present);
+        }
+    });
+}
+
+// leaveModule tells the server the page was closed. Beacons
+// cannot carry headers, the CSRF token is sent as form field.
+function leaveModule(element) {
+
+    if (navigator.sendBeacon) {
+
+        var form = new FormData();
+        form.append("csrf-token", $("meta[name='csrf-token']").attr("content"));
+
+        navigator.sendBeacon("/review/module/" + element.data("module") + "/leave", form);
+    }
+}
+
+// loadPresence marks all listed modules someone
+// else currently has open.
+function loadPresence() {
+
+    $.get("/modules/presence", function(data) {
+
+        if (data.Success) {
+
+            $(".module-presence").each(function() {
+
+                var element = $(this).empty();
+                var present = data.Modules[element.data("module")] || [];
+
+                if (present.length > 0) {
+                    element.append($("<span class = \"label label-info\"></span>")
+                        .attr("title", "Gerade geöffnet von: " + presenceNames(present))
+                        .append($("<span class = \"glyphicon glyphicon-user\"></span>"))
+                        .append(document.createTextNode(" " + present.length)));
+                }
+            });
+        }
+    });
+}
+
+$(function() {
+
+    var element = $("#module-presence");
+
+    if (element.length > 0) {
+
+        sendHeartbeat(element);
+        setInterval(function() {
+            sendHeartbeat(element);
+        }, presenceInterval * 1000);
+
+        $(window).on("pagehide", function() {
+            leaveModule(element);
+        });
+    } else if ($(".module-presence").length > 0) {
+
+        loadPresence();
+        setInterval(loadPresence, presenceInterval * 1000);
+    }
+})
\ No newline at end of file
@@ -0,0 +1,90 @@
+// Offer reviewers to propose a replacement text for each
+// free text field, next to the field itself.
+function renderSuggestButtons(moduleID) {
+
+    $(".anchorable").each(function() {
+
+        var catID = $(this).data("category");
+
+        // Only users allowed to comment may suggest changes.
+        if ($("#comment-form-" + catID).length === 0) {
+            return;
+        }
+
+        var button = $("<button class = \"btn btn-default btn-xs suggestion-open\"></button>")
+            .data("module", moduleID).data("field", $(this).data("field")).data("category", catID)
+            .text("Änderung vorschlagen");
+
+        $(this).closest("p").after($("<div class = \"suggestion-editor\"></div>").append(button));
+    });
+}
+
+function openSuggestion(button) {
+
+    var editor = button.closest(".suggestion-editor");
+    if (editor.find("textarea").length > 0) {
+        return;
+    }
+
+    // The text content of a field equals its stored text.
+    var original = $(".anchorable[data-field=" + button.data("field") + "]").text();
+
+    editor.append($("<textarea class = \"form-control feedback-textarea suggestion-text\" rows = \"7\"></textarea>").val(original));
+    editor.append($("<input type = \"text\" class = \"form-control suggestion-comment\" placeholder = \"Begründung (optional)\" />"));
+    editor.append($("<button class = \"btn btn-primary btn-sm suggestion-submit\"></button>").text("Vorschlag abgeben"));
+    editor.append(" ");
+    editor.append($("<button class = \"btn btn-link btn-sm suggestion-cancel\"></button>").text("Abbrechen"));
+
+    button.hide();
+}
+
+function closeSuggestion(editor) {
+    editor.find("textarea, input, .suggestion-submit, .suggestion-cancel").remove();
+    editor.find(".suggestion-open").show();
+}
+
+function submitSuggestion(editor) {
+
+    var button = editor.find(".suggestion-open");
+    var moduleID = button.data("module");
+
+    var obj = {
+        kind: "suggestion",
+        category: button.data("category"),
+        "suggestion-field": button.data("field"),
+        suggestion: editor.find(".suggestion-text").val(),
+        comment: editor.find(".suggestion-comment").val(),
+        severity: "minor",
+        parent: 0
+    };
+
+    $.post("/review/module/" + moduleID + "/add", obj, function(data) {
+
+        if (data.Success) {
+            closeSuggestion(editor);
+            loadFeedback(moduleID);
+        }
+    }).fail(function() {
+        alert("Der Vorschlag ändert den Text nicht oder ist zu lang.");
+    });
+}
+
+$(function() {
+
+    var path = window.location.pathname;
+    var moduleID = path.split("/")[3];
+
+    renderSuggestButtons(moduleID);
+
+    $(document).on("click", ".suggestion-open", function() {
+        openSuggestion($(this));
+    });
+
+    $(document).on("click", ".suggestion-cancel", function() {
+        closeSuggestion($(this).closest(".suggestion-editor"));
+    });
+
+    $(document).on("click", ".suggestion-submit", function() {
+        submitSuggestion($(this).closest(".suggestion-editor"));
+    });
+})
\ No newline at end of file
@@ -0,0 +1,18 @@
+$.extend(true, $.fn.dataTable.defaults, {
+    "searching": false
+});
+
+$(function() {
+
+    $('#usersList').DataTable({
+        "order": [[ 1, "asc" ]],
+        "paging": false,
+        "info": false,
+        "fixedHeader": {
+            "header": true,
+            "footer": false
+        }
+    });
+
+    $('[data-toggle="tooltip"]').tooltip();
+});
\ No newline at end of file
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"github.com/freitagsrunde/modulist/db"
+)
+
+// Constants
+
+const (
+	// Longest replacement text a suggestion may propose.
+	MAX_SUGGESTION_LENGTH = 20000
+)
+
+// Functions
+
+// ResolveSuggestions compares the proposals of all
+// suggestions among supplied discussions with the text
+// they were made for and marks the ones whose field has
+// changed in supplied module since.
+func ResolveSuggestions(Module db.Module, Threads []db.Feedback) {
+
+	for i := range Threads {
+
+		if !Threads[i].IsSuggestion() {
+			continue
+		}
+
+		Threads[i].Diff = Threads[i].SuggestionDiff()
+
+		if Text, ok := Module.AnchorText(Threads[i].SuggestionField); ok {
+			Threads[i].SuggestionOutdated = (Text != Threads[i].SuggestionOriginal)
+		}
+	}
+}
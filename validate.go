@@ -34,6 +34,10 @@ func (app *App) ConformAndValidate(Payload interface{}) map[string]string {
 				errResp[err.Field()] = "Das folgende Feld enthält keine valide Mail-Adresse"
 			} else if err.Tag() == "uuid4" {
 				errResp[err.Field()] = "Die ID des Nutzers muss ein bestimmtes Format erfüllen"
+			} else if err.Tag() == "max" {
+				errResp[err.Field()] = "Das folgende Feld enthält zu viele Zeichen"
+			} else {
+				errResp[err.Field()] = "Das folgende Feld enthält einen ungültigen Wert"
 			}
 		}
 
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/freitagsrunde/modulist/db"
+	"github.com/freitagsrunde/modulist/markdown"
+)
+
+// Constants
+
+const (
+	// Subject of mails summarizing events on watched modules.
+	WATCH_DIGEST_SUBJECT = "Neues zu deinen beobachteten Modulen in MODULIST"
+
+	// How often to check whether digests are due.
+	WATCH_DIGEST_CHECK_INTERVAL = time.Hour
+
+	// Characters of a comment quoted in digests.
+	WATCH_DIGEST_EXCERPT_LENGTH = 200
+)
+
+// Functions
+
+// watchDigestPeriod returns how much time a digest
+// of supplied frequency covers, 0 if it is disabled.
+func watchDigestPeriod(Frequency string) time.Duration {
+
+	switch Frequency {
+
+	case db.WATCH_DIGEST_DAILY:
+		return 24 * time.Hour
+
+	case db.WATCH_DIGEST_WEEKLY:
+		return 7 * 24 * time.Hour
+	}
+
+	return 0
+}
+
+// excerpt shortens a comment to one line of plain text.
+func excerpt(Comment string) string {
+
+	Text := []rune(strings.Join(strings.Fields(markdown.ToPlain(Comment)), " "))
+	if len(Text) > WATCH_DIGEST_EXCERPT_LENGTH {
+		return string(Text[:WATCH_DIGEST_EXCERPT_LENGTH]) + "…"
+	}
+
+	return string(Text)
+}
+
+// RecordEvent stores that supplied user acted on supplied
+// feedback, to be included in digests of watching users.
+// Status is only set for status changes.
+func (app *App) RecordEvent(Actor db.User, Feedback db.Feedback, Kind string, Status string) {
+
+	// Actor, module and feedback already exist, only store the references.
+	app.DB.Set("gorm:save_associations", false).Create(&db.ModuleEvent{
+		ModuleID:   Feedback.ModuleID,
+		ActorID:    Actor.ID,
+		FeedbackID: Feedback.ID,
+		Kind:       Kind,
+		Status:     Status,
+		Created:    time.Now(),
+	})
+}
+
+// describeEvent returns one line of a digest describing
+// what the actor of supplied event did.
+func describeEvent(Event db.ModuleEvent) string {
+
+	Actor := fmt.Sprintf("%s %s", Event.Actor.FirstName, Event.Actor.LastName)
+
+	switch Event.Kind {
+
+	case db.EVENT_COMMENT:
+		return fmt.Sprintf("%s kommentierte „%s“: %s", Actor, db.CategoryTitles()[Event.Feedback.Category], excerpt(Event.Feedback.Comment))
+
+	case db.EVENT_REPLY:
+		return fmt.Sprintf("%s antwortete auf einen Kommentar: %s", Actor, excerpt(Event.Feedback.Comment))
+
+	case db.EVENT_STATUS:
+		return fmt.Sprintf("%s setzte den Status eines Kommentars auf „%s“: %s", Actor, db.StatusTitles()[Event.Status], excerpt(Event.Feedback.Comment))
+
+	case db.EVENT_CONSENSUS:
+		return fmt.Sprintf("%s markierte eine Antwort als Konsens: %s", Actor, excerpt(Event.Feedback.Comment))
+	}
+
+	return Actor
+}
+
+// visibleToOwner leaves out events on comments module owners
+// do not get to see. Replies are visible if their top-level
+// comment is, status changes only if the new status is.
+func (app *App) visibleToOwner(Events []db.ModuleEvent) []db.ModuleEvent {
+
+	Filter := OwnerFeedbackFilter()
+
+	ParentIDs := []int{}
+	for _, Event := range Events {
+
+		if Event.Feedback.ParentID != 0 {
+			ParentIDs = append(ParentIDs, Event.Feedback.ParentID)
+		}
+	}
+
+	Parents := make(map[int]db.Feedback)
+	if len(ParentIDs) > 0 {
+
+		var Loaded []db.Feedback
+		app.DB.Where("\"id\" IN (?)", ParentIDs).Find(&Loaded)
+
+		for _, Parent := range Loaded {
+			Parents[Parent.ID] = Parent
+		}
+	}
+
+	Visible := []db.ModuleEvent{}
+	for _, Event := range Events {
+
+		Thread := Event.Feedback
+		if Thread.ParentID != 0 {
+			Thread = Parents[Thread.ParentID]
+		}
+
+		if !Filter.Matches(Thread) {
+			continue
+		}
+
+		if (Event.Kind == db.EVENT_STATUS) && !Filter.Matches(db.Feedback{Status: Event.Status}) {
+			continue
+		}
+
+		Visible = append(Visible, Event)
+	}
+
+	return Visible
+}
+
+// ComposeWatchDigest lists supplied events as plain text,
+// grouped by module, each module with a link to its
+// feedback. Module owners without permission to review
+// are linked to their own view of the module.
+func ComposeWatchDigest(User db.User, Events []db.ModuleEvent, Since time.Time, BaseURL string) string {
+
+	var Body strings.Builder
+
+	fmt.Fprintf(&Body, "Hallo %s,\n\nseit dem %s gab es Neues zu Modulen, die du beobachtest:\n",
+		User.FirstName, Since.Format("02.01.2006 15:04"))
+
+	Path := "review"
+	if !User.Can(db.PERMISSION_READ) {
+		Path = "owner"
+	}
+
+	for i, Event := range Events {
+
+		if (i == 0) || (Events[i-1].ModuleID != Event.ModuleID) {
+
+			Module := fmt.Sprintf("Modul #%d", Event.Module.ModuleID)
+			if Event.Module.Title.Valid {
+				Module = fmt.Sprintf("„%s“ (#%d)", Event.Module.Title.String, Event.Module.ModuleID)
+			}
+
+			fmt.Fprintf(&Body, "\n%s\n%s/%s/module/%d\n\n", Module, BaseURL, Path, Event.ModuleID)
+		}
+
+		fmt.Fprintf(&Body, "* %s: %s\n", Event.Created.Format("02.01.2006 15:04"), indentLines(describeEvent(Event), "  "))
+	}
+
+	fmt.Fprintf(&Body, "\nWie oft du diese Zusammenfassung erhältst, kannst du unter %s/settings einstellen.\n", BaseURL)
+
+	return Body.String()
+}
+
+// SendWatchDigests mails every user whose digest is due
+// what others did on the watched modules since the last
+// digest. Users without any such events receive no mail.
+func (app *App) SendWatchDigests() {
+
+	var Users []db.User
+	app.DB.Preload("Roles").Where("\"watch_digest\" <> ? AND \"enabled\" = ?", db.WATCH_DIGEST_OFF, true).Find(&Users)
+
+	Now := time.Now()
+
+	for _, User := range Users {
+
+		Period := watchDigestPeriod(User.WatchDigest)
+		if Period == 0 {
+			continue
+		}
+
+		// Allow for the interval of checks, so that
+		// digests do not drift later each time.
+		Since := Now.Add(-Period)
+		if User.WatchDigestSent != nil {
+
+			if Now.Before(User.WatchDigestSent.Add(Period - (WATCH_DIGEST_CHECK_INTERVAL / 2))) {
+				continue
+			}
+
+			Since = *User.WatchDigestSent
+		}
+
+		Events := db.ListModuleEvents(app.DB, db.WatchedModuleIDs(app.DB, User), Since, User.ID)
+
+		// Owners must not learn about feedback hidden from them.
+		if !User.Can(db.PERMISSION_READ) {
+			Events = app.visibleToOwner(Events)
+		}
+
+		if len(Events) > 0 {
+
+			err := app.Mailer.Send([]string{User.Mail}, WATCH_DIGEST_SUBJECT, ComposeWatchDigest(User, Events, Since, app.BaseURL))
+			if err != nil {
+				log.Printf("[SendWatchDigests] Sending digest to '%s' failed: %s.\n", User.Mail, err.Error())
+
+				continue
+			}
+		}
+
+		app.DB.Model(&User).Update("watch_digest_sent", Now)
+	}
+}
@@ -0,0 +1,219 @@
+package workload
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/freitagsrunde/modulist/db"
+)
+
+// Constants
+
+const (
+	// Hours of work one ECTS credit stands for.
+	HOURS_PER_ECTS = 30
+
+	// Weeks of lectures per term, one credit hour
+	// (SWS) stands for one hour in each of them.
+	WEEKS_PER_TERM = 15
+
+	// Deviation in hours tolerated when comparing
+	// sums of working efforts, due to rounding.
+	HOURS_TOLERANCE = 0.5
+)
+
+// Variables
+
+// presenceKeywords recognize working efforts
+// describing time spent attending a course.
+var presenceKeywords = []string{"präsenz", "anwesenheit", "kontakt"}
+
+// Structs
+
+// Group is the working effort of one category, as
+// grouped by db.WorkingEffortsConvert.
+type Group struct {
+	Category string
+	Hours    float64
+}
+
+// Mismatch is a working effort whose total differs
+// from its multiplier times its hours.
+type Mismatch struct {
+	Category    string
+	Description string
+	Multiplier  float64
+	Hours       float64
+	Total       float64
+	Expected    float64
+}
+
+// CourseLoad compares the credit hours of a course with
+// the presence time listed in its working effort. Found
+// tells whether working effort for the course was listed.
+type CourseLoad struct {
+	Title         string
+	CreditHours   int64
+	ContactHours  float64
+	PresenceHours float64
+	Found         bool
+}
+
+// Summary is the workload of a module derived from its
+// working efforts, compared with its credits and the
+// credit hours of its courses.
+type Summary struct {
+	ECTS          int
+	TotalHours    float64
+	ExpectedHours float64
+	HoursPerECTS  float64
+	CreditHours   int64
+	Groups        []Group
+	Mismatches    []Mismatch
+	Courses       []CourseLoad
+	Warnings      []string
+}
+
+// Functions
+
+// round rounds hours to two decimals, removing
+// artifacts of the stored single precision.
+func round(Hours float64) float64 {
+	return math.Round(Hours*100) / 100
+}
+
+// differs reports whether two amounts of hours
+// differ by more than the tolerated deviation.
+func differs(a float64, b float64) bool {
+	return math.Abs(a-b) > HOURS_TOLERANCE
+}
+
+// isPresence reports whether supplied description of a
+// working effort refers to attending the course.
+func isPresence(Description string) bool {
+
+	Lower := strings.ToLower(Description)
+	for _, Keyword := range presenceKeywords {
+
+		if strings.Contains(Lower, Keyword) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Calculate derives the workload summary of supplied
+// module. Working efforts and courses have to be loaded.
+func Calculate(Module db.Module) Summary {
+
+	Summary := Summary{
+		ECTS:          Module.ECTS,
+		ExpectedHours: float64(Module.ECTS * HOURS_PER_ECTS),
+		Groups:        []Group{},
+		Mismatches:    []Mismatch{},
+		Courses:       []CourseLoad{},
+		Warnings:      []string{},
+	}
+
+	Groups := db.WorkingEffortsConvert(Module.WorkingEfforts)
+
+	for _, Grouped := range Groups {
+
+		Summary.Groups = append(Summary.Groups, Group{
+			Category: Grouped.Category,
+			Hours:    round(float64(Grouped.CourseTotal)),
+		})
+		Summary.TotalHours += float64(Grouped.CourseTotal)
+
+		for _, Effort := range Grouped.Efforts {
+
+			Expected := round(float64(Effort.Multiplier) * float64(Effort.Hours))
+			if differs(float64(Effort.Total), Expected) {
+
+				Summary.Mismatches = append(Summary.Mismatches, Mismatch{
+					Category:    Grouped.Category,
+					Description: Effort.Description,
+					Multiplier:  round(float64(Effort.Multiplier)),
+					Hours:       round(float64(Effort.Hours)),
+					Total:       round(float64(Effort.Total)),
+					Expected:    Expected,
+				})
+			}
+		}
+	}
+
+	Summary.TotalHours = round(Summary.TotalHours)
+	if Module.ECTS > 0 {
+		Summary.HoursPerECTS = round(Summary.TotalHours / float64(Module.ECTS))
+	}
+
+	for _, Course := range Module.Courses {
+
+		if !Course.CreditHours.Valid {
+			continue
+		}
+
+		Load := CourseLoad{
+			Title:        Course.Title,
+			CreditHours:  Course.CreditHours.Int64,
+			ContactHours: float64(Course.CreditHours.Int64 * WEEKS_PER_TERM),
+		}
+		Summary.CreditHours += Course.CreditHours.Int64
+
+		// Working efforts reference the course they belong to.
+		for _, Effort := range Module.WorkingEfforts {
+
+			if !Effort.CourseID.Valid || (Effort.CourseID.Int64 != int64(Course.ID)) {
+				continue
+			}
+
+			Load.Found = true
+			if isPresence(Effort.Description) {
+				Load.PresenceHours += float64(Effort.Total)
+			}
+		}
+
+		Load.PresenceHours = round(Load.PresenceHours)
+		Summary.Courses = append(Summary.Courses, Load)
+	}
+
+	Summary.Warnings = warnings(Summary)
+
+	return Summary
+}
+
+// MatchesECTS reports whether the total working effort
+// equals the hours the credits of the module stand for.
+func (summary Summary) MatchesECTS() bool {
+	return !differs(summary.TotalHours, summary.ExpectedHours)
+}
+
+// warnings describes all inconsistencies of a summary.
+func warnings(Summary Summary) []string {
+
+	Warnings := []string{}
+
+	if len(Summary.Groups) == 0 {
+		Warnings = append(Warnings, "Der Arbeitsaufwand ist nicht aufgeschlüsselt.")
+	} else if !Summary.MatchesECTS() {
+		Warnings = append(Warnings, fmt.Sprintf("Der Arbeitsaufwand summiert sich auf %g Stunden, %d Leistungspunkte entsprechen aber %g Stunden.",
+			Summary.TotalHours, Summary.ECTS, Summary.ExpectedHours))
+	}
+
+	for _, Mismatch := range Summary.Mismatches {
+		Warnings = append(Warnings, fmt.Sprintf("„%s“ (%s): %g × %gh ergibt %gh, angegeben sind aber %gh.",
+			Mismatch.Description, Mismatch.Category, Mismatch.Multiplier, Mismatch.Hours, Mismatch.Expected, Mismatch.Total))
+	}
+
+	for _, Course := range Summary.Courses {
+
+		if Course.Found && (Course.PresenceHours > 0) && differs(Course.PresenceHours, Course.ContactHours) {
+			Warnings = append(Warnings, fmt.Sprintf("„%s“ umfasst %d SWS, also %g Stunden Präsenzzeit, im Arbeitsaufwand sind aber %g Stunden angegeben.",
+				Course.Title, Course.CreditHours, Course.ContactHours, Course.PresenceHours))
+		}
+	}
+
+	return Warnings
+}